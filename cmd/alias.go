@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/debdutdeb/novm/v3/pkg/n"
+	"github.com/spf13/cobra"
+)
+
+type alias struct {
+	Name   string `json:"name"`
+	Target string `json:"target"`
+
+	// Spec is what Target ends up at through other aliases, if it's one
+	Spec  string `json:"spec,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+func listAliases(rootDir string) ([]alias, error) {
+	aliases, err := n.Aliases(rootDir)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]alias, 0, len(aliases))
+
+	for name, target := range aliases {
+		a := alias{Name: name, Target: target}
+
+		if spec, _, err := n.ExpandAlias(rootDir, target); err != nil {
+			a.Error = err.Error()
+		} else if spec != target {
+			a.Spec = spec
+		}
+
+		list = append(list, a)
+	}
+
+	slices.SortFunc(list, func(a, b alias) int { return strings.Compare(a.Name, b.Name) })
+
+	return list, nil
+}
+
+func aliasListCmd() *cobra.Command {
+	var asJson bool
+
+	cmd := cobra.Command{
+		Use:     "ls",
+		Aliases: []string{"list"},
+		Short:   "List the aliases",
+		Args:    cobra.NoArgs,
+		RunE: func(c *cobra.Command, args []string) error {
+			s, err := loadSettings(".")
+			if err != nil {
+				return err
+			}
+
+			list, err := listAliases(s.RootDir)
+			if err != nil {
+				return err
+			}
+
+			if asJson {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(list)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+
+			for _, a := range list {
+				switch {
+				case a.Error != "":
+					fmt.Fprintf(w, "%s\t-> %s\t(error: %s)\n", a.Name, a.Target, a.Error)
+				case a.Spec != "":
+					fmt.Fprintf(w, "%s\t-> %s\t(-> %s)\n", a.Name, a.Target, a.Spec)
+				default:
+					fmt.Fprintf(w, "%s\t-> %s\t\n", a.Name, a.Target)
+				}
+			}
+
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().BoolVar(&asJson, "json", false, "print as json")
+
+	return &cmd
+}
+
+func aliasSetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <name> <version>",
+		Short: "Point an alias at a version",
+		Long:  "point an alias at a version, a range, latest, an lts name or another alias. Sources can then use the alias as their version.",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(c *cobra.Command, args []string) error {
+			s, err := loadSettings(".")
+			if err != nil {
+				return err
+			}
+
+			return n.SetAlias(s.RootDir, args[0], args[1], nodeOptions(s)...)
+		},
+	}
+}
+
+func aliasRemoveCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "rm <name>",
+		Aliases: []string{"remove", "unset"},
+		Short:   "Remove an alias",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			s, err := loadSettings(".")
+			if err != nil {
+				return err
+			}
+
+			return n.RemoveAlias(s.RootDir, args[0])
+		},
+	}
+}
+
+func aliasCmd() *cobra.Command {
+	list := aliasListCmd()
+
+	cmd := cobra.Command{
+		Use:   "alias",
+		Short: "Name versions",
+		Long:  "manage aliases, names any version source can use instead of a version. Without a subcommand, same as alias ls",
+		Args:  list.Args,
+		RunE:  list.RunE,
+	}
+
+	cmd.Flags().AddFlagSet(list.Flags())
+
+	cmd.AddCommand(list, aliasSetCmd(), aliasRemoveCmd())
+
+	return &cmd
+}
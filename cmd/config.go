@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/debdutdeb/novm/v3/config"
+	"github.com/spf13/cobra"
+)
+
+func printSettings(s *config.Settings) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+
+	for _, file := range []struct{ name, path string }{{"user config", s.User}, {"project config", s.Project}} {
+		if file.path == "" {
+			file.path = "-"
+		}
+
+		fmt.Fprintf(w, "%s:\t%s\n", file.name, file.path)
+	}
+
+	fmt.Fprintln(w)
+
+	for _, key := range s.Keys() {
+		value, _ := s.Get(key)
+		fmt.Fprintf(w, "%s\t%s\t(%s)\n", key, value, s.Origin(key))
+	}
+
+	w.Flush()
+}
+
+func configListCmd() *cobra.Command {
+	var asJson bool
+
+	cmd := cobra.Command{
+		Use:   "list [dir]",
+		Short: "Print the effective settings and where they come from",
+		Long:  "print the settings novm runs with in dir (default current directory): flags over environment variables, over the nearest " + config.ProjectFile + ", over the user config, over the defaults",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			dir := "."
+			if len(args) == 1 {
+				dir = args[0]
+			}
+
+			s, err := loadSettings(dir)
+			if err != nil {
+				return err
+			}
+
+			if asJson {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(s)
+			}
+
+			printSettings(s)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&asJson, "json", false, "print as json")
+
+	return &cmd
+}
+
+func configGetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <key>",
+		Short: "Print the effective value of a setting",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			s, err := loadSettings(".")
+			if err != nil {
+				return err
+			}
+
+			value, err := s.Get(args[0])
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(value)
+
+			return nil
+		},
+	}
+}
+
+// configFile is the file set and unset write to
+func configFile(project bool) (string, error) {
+	if !project {
+		return config.UserFile(), nil
+	}
+
+	s, err := loadSettings(".")
+	if err != nil {
+		return "", err
+	}
+
+	if s.Project != "" {
+		return s.Project, nil
+	}
+
+	return config.ProjectFile, nil
+}
+
+func configSetCmd() *cobra.Command {
+	var project bool
+
+	cmd := cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Set a setting in the user config",
+		Long:  "set a setting in the user config, or with --project in the nearest " + config.ProjectFile + " (created in the current directory if there is none). Lists are comma separated.",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(c *cobra.Command, args []string) error {
+			path, err := configFile(project)
+			if err != nil {
+				return err
+			}
+
+			return config.Set(path, args[0], args[1])
+		},
+	}
+
+	cmd.Flags().BoolVar(&project, "project", false, "write to "+config.ProjectFile+" instead")
+
+	return &cmd
+}
+
+func configUnsetCmd() *cobra.Command {
+	var project bool
+
+	cmd := cobra.Command{
+		Use:   "unset <key>",
+		Short: "Remove a setting from the user config",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			path, err := configFile(project)
+			if err != nil {
+				return err
+			}
+
+			return config.Unset(path, args[0])
+		},
+	}
+
+	cmd.Flags().BoolVar(&project, "project", false, "remove from "+config.ProjectFile+" instead")
+
+	return &cmd
+}
+
+func configCmd() *cobra.Command {
+	list := configListCmd()
+
+	cmd := cobra.Command{
+		Use:   "config",
+		Short: "Print or change settings",
+		Long:  "without a subcommand, same as config list",
+		Args:  list.Args,
+		RunE:  list.RunE,
+	}
+
+	cmd.Flags().AddFlagSet(list.Flags())
+
+	cmd.AddCommand(list, configGetCmd(), configSetCmd(), configUnsetCmd())
+
+	return &cmd
+}
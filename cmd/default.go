@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/debdutdeb/novm/v3/pkg/n"
+	"github.com/spf13/cobra"
+)
+
+func defaultCmd() *cobra.Command {
+	var unset bool
+
+	cmd := cobra.Command{
+		Use:   "default [version]",
+		Short: "Print or set the default nodejs version",
+		Long:  "print or set the version used when no source has one, a version, a range, latest, an lts name or an alias. Without a default, the latest installed version is used.",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			settings, err := loadSettings(".")
+			if err != nil {
+				return err
+			}
+
+			if unset {
+				if len(args) > 0 {
+					return fmt.Errorf("--unset takes no version")
+				}
+
+				err := n.RemoveAlias(settings.RootDir, n.DefaultAlias)
+				if errors.Is(err, n.ErrAliasNotFound) {
+					return nil
+				}
+
+				return err
+			}
+
+			if len(args) == 0 {
+				spec, ok, err := n.Default(settings.RootDir)
+				if err != nil {
+					return err
+				}
+
+				if !ok {
+					fmt.Println("no default version, the latest installed version is used")
+					return nil
+				}
+
+				fmt.Println(spec)
+
+				return nil
+			}
+
+			// refuse what can't be resolved, unless it's only that offline
+			// nothing installed matches it
+			release, resolveErr := n.NewResolver(settings.RootDir, nodeOptions(settings)...).Resolve(args[0])
+			if resolveErr != nil && !(errors.Is(resolveErr, n.ErrOffline) && errors.Is(resolveErr, n.ErrNoMatchingRelease)) {
+				return resolveErr
+			}
+
+			if err := n.SetAlias(settings.RootDir, n.DefaultAlias, args[0]); err != nil {
+				return err
+			}
+
+			if resolveErr != nil {
+				log.Printf("%s can't be resolved offline, it will be once the release index can be fetched", args[0])
+				return nil
+			}
+
+			fmt.Printf("default -> %s (%s)\n", args[0], release.Version)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&unset, "unset", false, "remove the default, go back to the latest installed version")
+
+	return &cmd
+}
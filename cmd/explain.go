@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/debdutdeb/novm/v3/pkg/n"
+	"github.com/debdutdeb/novm/v3/pkg/sources"
+	"github.com/spf13/cobra"
+)
+
+type explainAttempt struct {
+	Dir       string   `json:"dir"`
+	Source    string   `json:"source"`
+	Path      string   `json:"path"`
+	Version   string   `json:"version,omitempty"`
+	Fallbacks []string `json:"fallbacks,omitempty"`
+	Error     string   `json:"error,omitempty"`
+	Selected  bool     `json:"selected,omitempty"`
+}
+
+type explanation struct {
+	Dir        string           `json:"dir"`
+	Depth      int              `json:"depth"`
+	Dirs       []string         `json:"dirs"`
+	Attempts   []explainAttempt `json:"attempts"`
+	Selected   *explainAttempt  `json:"selected"`
+	Resolution *n.Resolution    `json:"resolution"`
+
+	// Default is the default version, set when no source matched and it is
+	// what was resolved
+	Default string `json:"default,omitempty"`
+
+	// Support is nil if the release schedule couldn't be fetched
+	Support *n.Support `json:"support,omitempty"`
+}
+
+func explain(dir string) (*explanation, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := loadSettings(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &explanation{Dir: dir, Depth: settings.Depth}
+	e.Dirs = sources.Dirs(dir, e.Depth)
+
+	for _, attempt := range settings.Registry().Trace(dir, e.Depth) {
+		a := explainAttempt{
+			Dir:       attempt.Dir,
+			Source:    attempt.Source.Name(),
+			Path:      attempt.Path,
+			Version:   attempt.Version,
+			Fallbacks: attempt.Fallbacks,
+		}
+
+		if attempt.Err != nil {
+			a.Error = attempt.Err.Error()
+		}
+
+		if e.Selected == nil && a.Version != "" {
+			a.Selected = true
+			e.Selected = &a
+		}
+
+		e.Attempts = append(e.Attempts, a)
+	}
+
+	opts := nodeOptions(settings)
+
+	var manager *n.N
+
+	if e.Selected == nil {
+		spec, ok, err := n.Default(settings.RootDir)
+		if err != nil || !ok {
+			return e, err
+		}
+
+		e.Default = spec
+
+		if manager, err = n.NewNodeManager(false, n.DefaultAlias, settings.RootDir, opts...); err != nil {
+			return nil, fmt.Errorf("failed to resolve the default version %q: %w", spec, err)
+		}
+	} else {
+		manager, err = n.NewNodeManager(false, e.Selected.Version, settings.RootDir, opts...)
+		for _, fallback := range e.Selected.Fallbacks {
+			if err == nil {
+				break
+			}
+
+			manager, err = n.NewNodeManager(false, fallback, settings.RootDir, opts...)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %q from %s: %w", e.Selected.Version, e.Selected.Path, err)
+		}
+	}
+
+	resolution := manager.Resolution()
+	e.Resolution = &resolution
+
+	if support, err := manager.Support(); err == nil {
+		e.Support = &support
+	}
+
+	return e, nil
+}
+
+func (e *explanation) print() {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(w, "Sources (from %s up to %s):\n", e.Dir, e.Dirs[len(e.Dirs)-1])
+
+	dir := ""
+	for _, a := range e.Attempts {
+		if a.Dir != dir {
+			dir = a.Dir
+			fmt.Fprintf(w, "  %s\n", dir)
+		}
+
+		value := a.Version
+		if a.Error != "" {
+			value = "error: " + a.Error
+		} else if value == "" {
+			value = "-"
+		} else if len(a.Fallbacks) > 0 {
+			value += " (then " + strings.Join(a.Fallbacks, ", ") + ")"
+		}
+
+		marker := ""
+		if a.Selected {
+			marker = "<- selected"
+		}
+
+		fmt.Fprintf(w, "    %s\t%s\t%s\t%s\n", a.Source, a.Path, value, marker)
+	}
+
+	w.Flush()
+
+	if e.Resolution == nil {
+		fmt.Println("\nNo source matched and there is no default version, novm falls back to the latest installed version.")
+		return
+	}
+
+	r := e.Resolution
+
+	fmt.Println()
+	if e.Selected == nil {
+		fmt.Println("No source matched, novm uses the default version.")
+		fmt.Println()
+		fmt.Fprintf(w, "Spec:\t%s (novm default)\n", r.Spec)
+	} else {
+		fmt.Fprintf(w, "Spec:\t%s (%s)\n", r.Spec, e.Selected.Source)
+	}
+	if len(r.Aliases) > 0 {
+		fmt.Fprintf(w, "Alias:\t%s\n", strings.Join(r.Aliases, " -> "))
+	}
+	if r.Channel != "" {
+		fmt.Fprintf(w, "Channel:\t%s\n", r.Channel)
+	}
+	if r.Constraint != "" {
+		fmt.Fprintf(w, "Constraint:\t%s\n", r.Constraint)
+	}
+	if r.Strategy != "" {
+		fmt.Fprintf(w, "Strategy:\t%s\n", r.Strategy)
+	}
+	if r.Offline {
+		fmt.Fprintf(w, "Offline:\tonly installed versions were considered\n")
+	}
+	if r.Stale {
+		fmt.Fprintf(w, "Index:\tolder than index_ttl, node refreshes it in the background, or run novm refresh\n")
+	}
+	if r.Unofficial {
+		fmt.Fprintf(w, "Archive:\t%s (unofficial build)\n", r.ArchiveType)
+	} else {
+		fmt.Fprintf(w, "Archive:\t%s\n", r.ArchiveType)
+	}
+	for _, c := range r.Skipped {
+		fmt.Fprintf(w, "Skipped:\t%s\t%s\n", c.Version, c.Reason)
+	}
+	fmt.Fprintf(w, "Resolved:\t%s\n", r.Version)
+	if r.UpgradedFrom != "" {
+		fmt.Fprintf(w, "Security:\tupgraded from %s\n", r.UpgradedFrom)
+	}
+	if r.SecurityRelease != "" {
+		fmt.Fprintf(w, "Security:\tvulnerable, fixed in %s\n", r.SecurityRelease)
+	}
+	if e.Support != nil {
+		fmt.Fprintf(w, "Support:\t%s\n", e.Support)
+	}
+
+	w.Flush()
+}
+
+func explainCmd() *cobra.Command {
+	var asJson bool
+
+	cmd := cobra.Command{
+		Use:   "explain [dir]",
+		Short: "Explain which nodejs version would run and why",
+		Long:  "list every version source tried while walking up from dir (default current directory), the one that won, and how it was resolved to a release",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			dir := "."
+			if len(args) == 1 {
+				dir = args[0]
+			}
+
+			e, err := explain(dir)
+			if err != nil {
+				return err
+			}
+
+			if asJson {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(e)
+			}
+
+			e.print()
+
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&asJson, "json", false, "print as json")
+
+	return &cmd
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/debdutdeb/novm/v3/pkg/n"
+	"github.com/spf13/cobra"
+)
+
+func lsRemoteCmd() *cobra.Command {
+	var (
+		filter n.Filter
+		since  string
+		asJson bool
+	)
+
+	cmd := cobra.Command{
+		Use:   "ls-remote [range]",
+		Short: "List the nodejs releases",
+		Long:  "list the releases in the release index, newest first, those satisfying range if given. Installed releases, security releases and releases without a build for this platform are marked.",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			settings, err := loadSettings(".")
+			if err != nil {
+				return err
+			}
+
+			if len(args) > 0 {
+				filter.Constraint = args[0]
+			}
+
+			if since != "" {
+				if filter.Since, err = time.Parse(time.DateOnly, since); err != nil {
+					return fmt.Errorf("invalid --since %q, expected a date like 2024-01-01", since)
+				}
+			}
+
+			// listing what's out is worth waiting for a fresh index
+			opts := append(nodeOptions(settings), n.WithBackgroundRefresh(false))
+
+			releases, err := n.NewResolver(settings.RootDir, opts...).Releases(filter)
+			if err != nil {
+				return err
+			}
+
+			if asJson {
+				if releases == nil {
+					releases = []n.ListedRelease{}
+				}
+
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(releases)
+			}
+
+			if len(releases) == 0 {
+				log.Println("no release matches")
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+
+			for _, release := range releases {
+				date := ""
+				if !release.Date.IsZero() {
+					date = release.Date.Format(time.DateOnly)
+				}
+
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", release.Version, orDash(date), orDash(release.Lts), orDash(release.Npm), strings.Join(marks(release), ", "))
+			}
+
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().StringVar(&filter.Lts, "lts", "", "only lts releases, of a line if given, e.g. --lts=iron")
+	cmd.Flags().Lookup("lts").NoOptDefVal = "*"
+	cmd.Flags().Uint64Var(&filter.Major, "major", 0, "only releases of this major version")
+	cmd.Flags().BoolVar(&filter.Security, "security", false, "only security releases")
+	cmd.Flags().StringVar(&since, "since", "", "only releases out on or after this date, e.g. 2024-01-01")
+	cmd.Flags().BoolVar(&asJson, "json", false, "print the releases as json")
+
+	return &cmd
+}
+
+// marks are the notes the table has for release
+func marks(release n.ListedRelease) []string {
+	var notes []string
+
+	if release.Installed {
+		notes = append(notes, "installed")
+	}
+
+	if release.Security {
+		notes = append(notes, "security")
+	}
+
+	if !release.Build {
+		notes = append(notes, "no "+release.ArchiveType+" build")
+	} else if release.Unofficial {
+		notes = append(notes, "unofficial build")
+	}
+
+	return notes
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/debdutdeb/novm/v3/pkg/n"
+	"github.com/spf13/cobra"
+)
+
+func refreshCmd() *cobra.Command {
+	var force bool
+
+	cmd := cobra.Command{
+		Use:   "refresh [channel]",
+		Short: "Refresh the cached release index",
+		Long:  "download the release index of channel, releases without one, if it is older than index_ttl, along with the release schedule and the unofficial builds index if they were ever downloaded. novm runs this in the background when it uses an out of date index.",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			settings, err := loadSettings(".")
+			if err != nil {
+				return err
+			}
+
+			opts := nodeOptions(settings)
+			if force {
+				opts = append(opts, n.WithMaxAge(0))
+			}
+
+			channel := ""
+			if len(args) > 0 {
+				channel = args[0]
+			}
+
+			return n.NewResolver(settings.RootDir, opts...).Refresh(channel)
+		},
+	}
+
+	cmd.Flags().BoolVar(&force, "force", false, "refresh it however old it is")
+
+	return &cmd
+}
+
+// RefreshInBackground starts novm refresh for channel in a process of its
+// own, so it goes on after this one exits. Whether it works out is only seen
+// on the next run.
+func RefreshInBackground(channel string) {
+	exe, err := os.Executable()
+	if err != nil {
+		return
+	}
+
+	args := []string{"refresh"}
+	if channel != "" {
+		args = append(args, channel)
+	}
+
+	cmd := exec.Command(exe, args...)
+	cmd.Env = append(os.Environ(), "NOVM_WAKE=cli")
+
+	// out of the terminal's process group, a ^C to node isn't for it
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+
+	if cmd.Start() == nil {
+		go cmd.Wait()
+	}
+}
@@ -2,17 +2,43 @@ package cmd
 
 import (
 	"github.com/debdutdeb/novm/v3/common"
+	"github.com/debdutdeb/novm/v3/config"
+	"github.com/debdutdeb/novm/v3/pkg/n"
 
 	"github.com/spf13/cobra"
 )
 
-func Root(rootDir string) *cobra.Command {
+// overrides are the -c key=value flags, they beat every other setting
+var overrides []string
+
+// loadSettings loads the settings for dir with the -c flags applied
+func loadSettings(dir string) (*config.Settings, error) {
+	return config.Load(dir, overrides...)
+}
+
+// nodeOptions are the options of n.NewNodeManager settings ask for
+func nodeOptions(s *config.Settings) []n.Option {
+	return []n.Option{
+		n.WithMirrors(s.Mirrors...),
+		n.WithUnofficialBuilds(s.UnofficialMirrors...),
+		n.WithSchedule(s.Schedule),
+		n.WithOffline(s.Offline),
+		n.WithStrategy(n.Strategy(s.Strategy)),
+		n.WithSecurityUpgrade(s.Security == config.SecurityUpgrade),
+		n.WithMaxAge(s.IndexMaxAge()),
+		n.WithBackgroundRefresh(s.IndexRefresh == config.RefreshBackground),
+	}
+}
+
+func Root() *cobra.Command {
 	cmd := &cobra.Command{
 		Use: common.BIN_NAME,
 	}
 
+	cmd.PersistentFlags().StringArrayVarP(&overrides, "config", "c", nil, "override a setting for this run, key=value (see novm config list)")
+
 	cmd.AddCommand(versionCommand())
-	cmd.AddCommand(setupCommand(), whereCmd())
+	cmd.AddCommand(setupCommand(), whereCmd(), explainCmd(), configCmd(), aliasCmd(), defaultCmd(), refreshCmd(), lsRemoteCmd())
 
 	return cmd
 }
@@ -3,9 +3,6 @@ package cmd
 import (
 	"fmt"
 
-	"runtime"
-
-	"github.com/debdutdeb/novm/v3/common"
 	"github.com/debdutdeb/novm/v3/internal/log"
 	"github.com/debdutdeb/novm/v3/pkg/n"
 	"github.com/spf13/cobra"
@@ -20,7 +17,11 @@ func whereCmd() *cobra.Command {
 		Long:    "get the location of installed version on disk",
 		Run: func(cmd *cobra.Command, args []string) {
 			version := args[0]
-			n, err := n.NewNodeManager(false, version, common.RootDir)
+			settings, err := loadSettings("")
+			if err != nil {
+				log.Fatal(err)
+			}
+			n, err := n.NewNodeManager(false, version, settings.RootDir, nodeOptions(settings)...)
 			if err != nil {
 				log.Fatal(err)
 			}
@@ -30,7 +31,7 @@ func whereCmd() *cobra.Command {
 				return
 			}
 
-			fmt.Printf("%s/%s/%s\n", common.Where(n.Version()), runtime.GOOS, runtime.GOARCH)
+			fmt.Println(n.InstallDir())
 		},
 	}
 	return &where
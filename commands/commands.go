@@ -3,51 +3,27 @@ package commands
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/debdutdeb/novm/v3/cmd"
 	"github.com/debdutdeb/novm/v3/common"
+	"github.com/debdutdeb/novm/v3/config"
 	"github.com/debdutdeb/novm/v3/pkg/n"
+	"github.com/debdutdeb/novm/v3/pkg/sources"
 	"github.com/debdutdeb/novm/v3/state"
+	"github.com/debdutdeb/novm/v3/utils/tty"
 
 	"golang.org/x/mod/semver"
 )
 
 var NodeJsVersion string = ""
 
-func init() {
-	if filepath.Base(os.Args[0]) == common.BIN_NAME {
-		return
-	}
-
-	sources := source{
-		sourceEnvironmentVariable: sourceEnvironment, // NODE_VERSION
-		sourcePackageJsonFile:     sourcePackageJson, // engines, volta
-		sourceNvmFile:             sourceNvmrc,
-		sourceNodeVersionFile:     sourceNodeVersion,
-		sourceToolVersionsFile:    wrapInExperimental(sourceToolVersionsFile, sourceToolVersions), // asdf, mise
-		sourceDockerfileFile:      wrapInExperimental(sourceDockerfileFile, sourceDockerfile),
-	}
-
-	depth := common.DepthSourceDetection()
-	dir := "."
-	for i := 0; i <= depth; i++ {
-		for sourceName, sourceFn := range sources {
-			v, err := sourceFn(dir)
-			if v != "" {
-				NodeJsVersion = v
-				return
-			}
-
-			if err != nil {
-				log.Println("unable to parse source:", sourceName, "error:", err)
-			}
-		}
-		dir = filepath.Join(dir, "..")
-	}
-}
+// detected is the source NodeJsVersion came from, if any
+var detected sources.Match
 
 type novmWakeCode = string
 
@@ -72,7 +48,7 @@ func Run() error {
 
 	switch c := wakeCode(); c {
 	case wakeCmd:
-		return cmd.Root(root).Execute()
+		return cmd.Root().Execute()
 	case wakeStateDump:
 		enc := json.NewEncoder(os.Stdout)
 		enc.SetIndent("", "  ")
@@ -82,27 +58,99 @@ func Run() error {
 	case "":
 	}
 
+	settings, err := config.Load(".")
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	if settings.LogLevel == config.LogError {
+		// errors are returned, everything logged on the way is informational
+		log.SetOutput(io.Discard)
+		defer log.SetOutput(os.Stderr)
+	}
+
+	st.Retention = settings.Retention()
+
+	if m, ok := settings.Registry().Detect(".", settings.Depth); ok {
+		NodeJsVersion = m.Version
+		detected = m
+	}
+
 	if NodeJsVersion == "" {
-		log.Println("no nodejs version detected from sources, using latest installed")
+		var isDefault bool
 
-		NodeJsVersion, err = findMaxInstalledVersion(filepath.Join(root, "versions"))
+		NodeJsVersion, isDefault, err = fallbackVersion(root)
 		if err != nil {
 			return fmt.Errorf("failed to detect current nodejs version: %w", err)
 		}
+
+		if isDefault {
+			log.Println("no nodejs version detected from sources, using the default version")
+		} else {
+			log.Println("no nodejs version detected from sources, using latest installed")
+		}
 	}
 
-	n, err := n.NewNodeManager(false, NodeJsVersion, root)
+	n, err := nodeManager(root,
+		n.WithMirrors(settings.Mirrors...),
+		n.WithUnofficialBuilds(settings.UnofficialMirrors...),
+		n.WithSchedule(settings.Schedule),
+		n.WithOffline(settings.Offline),
+		n.WithStrategy(n.Strategy(settings.Strategy)),
+		n.WithSecurityUpgrade(settings.Security == config.SecurityUpgrade),
+		n.WithMaxAge(settings.IndexMaxAge()),
+		n.WithBackgroundRefresh(settings.IndexRefresh == config.RefreshBackground),
+	)
 	if err != nil {
 		return fmt.Errorf("failed to initialize node manager: %w", err)
 	}
 
+	if n.Resolution().Stale {
+		cmd.RefreshInBackground(n.Channel())
+	}
+
+	r := n.Resolution()
+
+	if r.UpgradedFrom != "" {
+		log.Printf("using nodejs %s instead of %s, a security release (security: %s)", r.Version, r.UpgradedFrom, settings.Origin("security"))
+	}
+
+	// with upgrade too, a newer minor or one the range doesn't allow isn't
+	// upgraded to
+	if r.SecurityRelease != "" && settings.Security != config.SecurityOff {
+		log.Printf("nodejs %s has known vulnerabilities, upgrade to security release %s or later, see https://nodejs.org/en/blog/vulnerability", r.Version, r.SecurityRelease)
+	}
+
+	if err := n.RefreshError(); err != nil {
+		log.Printf("failed to refresh the release index, using the cached copy: %v", err)
+	}
+
+	if n.Offline() && !settings.Offline {
+		log.Printf("the release index can't be fetched, using installed nodejs %s", n.Version())
+	}
+
+	if err := checkSupport(settings, n); err != nil {
+		return err
+	}
+
+	if !settings.AutoInstall && !n.Installed() {
+		return fmt.Errorf("nodejs %s is not installed and auto_install is off (%s)", n.Resolution().Version, settings.Origin("auto_install"))
+	}
+
 	err = n.EnsureInstalled()
 	if err != nil {
 		return fmt.Errorf("failed to install node version %w", err)
 	}
 
-	if err := st.IncPoolHit(n.Version()); err != nil {
-		return fmt.Errorf("failed to update pool control for version %s: %w", n.Version(), err)
+	for _, key := range settings.EnvKeys() {
+		n.Setenv(key, settings.Env[key])
+	}
+
+	// prerelease builds aren't part of the pool, they are kept until removed
+	if n.Channel() == "" {
+		if err := st.IncPoolHit(n.Version()); err != nil {
+			return fmt.Errorf("failed to update pool control for version %s: %w", n.Version(), err)
+		}
 	}
 
 	thisV := n.Version()
@@ -117,11 +165,12 @@ func Run() error {
 			return n.Npm().Run(os.Args[1:]...)
 		}, notCurrentVersion)
 	case "yarn":
-		if err := installIfNotExists(n, "yarn"); err != nil {
+		yarn, err := packageManager(settings, n, "yarn", n.Yarn, n.YarnVersion)
+		if err != nil {
 			return err
 		}
 		return st.WhileCompactingPool(func(_s *state.State) error {
-			return n.Yarn().Run(os.Args[1:]...)
+			return yarn.Run(os.Args[1:]...)
 		}, notCurrentVersion)
 	case "npx":
 		return st.WhileCompactingPool(func(_s *state.State) error {
@@ -132,11 +181,12 @@ func Run() error {
 			return n.Corepack().Run(os.Args[1:]...)
 		}, notCurrentVersion)
 	case "pnpm":
-		if err := installIfNotExists(n, "pnpm"); err != nil {
+		pnpm, err := packageManager(settings, n, "pnpm", n.Pnpm, n.PnpmVersion)
+		if err != nil {
 			return err
 		}
 		return st.WhileCompactingPool(func(_s *state.State) error {
-			return n.Pnpm().Run(os.Args[1:]...)
+			return pnpm.Run(os.Args[1:]...)
 		}, notCurrentVersion)
 	}
 
@@ -145,6 +195,91 @@ func Run() error {
 	}, notCurrentVersion)
 }
 
+// nodeManager resolves NodeJsVersion, then the fallbacks its source listed.
+// If none of them can be, it falls back to the default version, or the latest
+// installed one, when the source is ok with that.
+func nodeManager(root string, opts ...n.Option) (*n.N, error) {
+	manager, err := n.NewNodeManager(false, NodeJsVersion, root, opts...)
+	if err == nil {
+		return manager, nil
+	}
+
+	for _, fallback := range detected.Fallbacks {
+		log.Printf("%s from %s can not be used, trying %s: %v", NodeJsVersion, detected.Path, fallback, err)
+
+		NodeJsVersion = fallback
+
+		if manager, err = n.NewNodeManager(false, NodeJsVersion, root, opts...); err == nil {
+			return manager, nil
+		}
+	}
+
+	if detected.OnFail != sources.OnFailWarn {
+		return nil, err
+	}
+
+	log.Printf("%s from %s can not be used, falling back to the default or latest installed version: %v", NodeJsVersion, detected.Path, err)
+
+	NodeJsVersion, _, err = fallbackVersion(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect current nodejs version: %w", err)
+	}
+
+	return n.NewNodeManager(false, NodeJsVersion, root, opts...)
+}
+
+// checkSupport warns about a version whose release line is past, or close to,
+// its end of life, and with eol=error refuses one that is past it. Not being
+// able to tell, e.g. without network, isn't worth a word.
+func checkSupport(settings *config.Settings, manager *n.N) error {
+	if settings.EOL == config.EOLOff || (settings.EOL == config.EOLWarn && !tty.IsInteractive()) {
+		return nil
+	}
+
+	support, err := manager.Support()
+	if err != nil {
+		return nil
+	}
+
+	version := manager.Resolution().Version
+
+	if support.Phase == n.PhaseEOL {
+		if settings.EOL == config.EOLError {
+			return fmt.Errorf("nodejs %s: %s, it no longer gets security fixes (eol: %s, %s)", version, support, settings.EOL, settings.Origin("eol"))
+		}
+
+		log.Printf("nodejs %s: %s, it no longer gets security fixes", version, support)
+
+		return nil
+	}
+
+	if left := support.Until(time.Now()); left < time.Duration(settings.EOLDays)*24*time.Hour {
+		log.Printf("nodejs %s: %s, in %d days", version, support, int(left.Hours()/24)+1)
+	}
+
+	return nil
+}
+
+// packageManager returns the version of bin the project's packageManager field
+// pins, or the global install if it doesn't pin one
+func packageManager[T any](settings *config.Settings, n *n.N, bin string, global func() T, pinned func(version string) (T, error)) (T, error) {
+	name, version, ok := sources.DetectPackageManager(".", settings.Depth)
+	if ok && name == bin && version != "" {
+		return pinned(version)
+	}
+
+	if ok && name != bin {
+		log.Printf("this project uses %s as its package manager, not %s", name, bin)
+	}
+
+	if err := installIfNotExists(n, bin); err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return global(), nil
+}
+
 func installIfNotExists(n *n.N, bin string) error {
 	path := filepath.Join(common.RootDir, "bin", bin)
 	fst, err := os.Stat(path)
@@ -162,6 +297,24 @@ func installIfNotExists(n *n.N, bin string) error {
 	return nil
 }
 
+// fallbackVersion is the version used when sources don't have one: the
+// default version if one is set, see novm default, else the latest installed
+// version
+func fallbackVersion(root string) (version string, isDefault bool, err error) {
+	if _, ok, err := n.Default(root); err != nil {
+		return "", false, err
+	} else if ok {
+		return n.DefaultAlias, true, nil
+	}
+
+	version, err = findMaxInstalledVersion(filepath.Join(root, "versions"))
+
+	return version, false, err
+}
+
+// findMaxInstalledVersion returns the newest version installed in rootDir,
+// latest if there is none. Anything in there that isn't an install of a
+// version is ignored.
 func findMaxInstalledVersion(rootDir string) (string, error) {
 	entries, err := os.ReadDir(rootDir)
 	if err != nil {
@@ -172,25 +325,21 @@ func findMaxInstalledVersion(rootDir string) (string, error) {
 		return "", err
 	}
 
-	if len(entries) == 0 {
-		return "latest", nil
-	}
-
-	if !semver.IsValid(entries[0].Name()) {
-		return "", fmt.Errorf("root install directory seems to be polluted with files unknown %s", entries[0].Name())
-	}
-
-	max := entries[0].Name()
+	max := ""
 
-	for _, entry := range entries[1:] {
-		if !semver.IsValid(entry.Name()) {
-			return "", fmt.Errorf("root install directory seems to be polluted with files unknown %s", entry.Name())
+	for _, entry := range entries {
+		if !entry.IsDir() || !semver.IsValid(entry.Name()) {
+			continue
 		}
 
-		if semver.Compare(entry.Name(), max) == 1 {
+		if max == "" || semver.Compare(entry.Name(), max) == 1 {
 			max = entry.Name()
 		}
 	}
 
+	if max == "" {
+		return "latest", nil
+	}
+
 	return max, nil
 }
@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/debdutdeb/novm/v3/pkg/n"
+)
+
+func TestFallbackVersion(t *testing.T) {
+	root := t.TempDir()
+	versions := filepath.Join(root, "versions")
+
+	if v, isDefault, err := fallbackVersion(root); err != nil || isDefault || v != "latest" {
+		t.Fatalf("expected latest without installs, got %q (%v, %v)", v, isDefault, err)
+	}
+
+	// not installs of a version, ignored
+	for _, dir := range []string{"v18.20.4", "v20.11.0", "v9.11.2", ".tmp", "junk"} {
+		if err := os.MkdirAll(filepath.Join(versions, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(versions, "v99.0.0"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if v, isDefault, err := fallbackVersion(root); err != nil || isDefault || v != "v20.11.0" {
+		t.Fatalf("expected v20.11.0, got %q (%v, %v)", v, isDefault, err)
+	}
+
+	if err := n.SetAlias(root, n.DefaultAlias, "^18"); err != nil {
+		t.Fatal(err)
+	}
+
+	if v, isDefault, err := fallbackVersion(root); err != nil || !isDefault || v != n.DefaultAlias {
+		t.Fatalf("expected the default, got %q (%v, %v)", v, isDefault, err)
+	}
+}
@@ -2,55 +2,41 @@ package common
 
 import (
 	"os"
-	"os/user"
 	"path/filepath"
 	"strconv"
 
 	"github.com/Masterminds/semver/v3"
+	"github.com/debdutdeb/novm/v3/config"
 	"github.com/debdutdeb/novm/v3/internal/log"
 )
 
 var RootDir string
 
 func init() {
-	workdir := os.Getenv("NOVM_WORKDIR")
-	if workdir != "" {
-		RootDir = workdir
-		return
-	}
-
-	root, err := rootDir()()
+	// the user config and the environment, a project can't move the root
+	settings, err := config.Load("")
 	if err != nil {
-		log.Fatalf("failed to detect current user: %v", err)
+		log.Fatalf("failed to load settings: %v", err)
 	}
 
-	RootDir = root
+	RootDir = settings.RootDir
 }
 
-const NOVM_DIR = ".novm"
+const NOVM_DIR = config.DirName
 
 const BIN_NAME = "novm"
 
-func rootDir() func() (string, error) {
-	u, err := user.Current()
-	if err != nil {
-		log.Fatalf("failed to detect current user: %v", err)
-	}
-
-	root := filepath.Join(u.HomeDir, NOVM_DIR)
-
-	return func() (string, error) {
-		return root, err
-	}
-}
-
+// DepthSourceDetection caps how many parent directories are searched for a
+// version source, -1 means up to the repository or workspace root
+//
+// Deprecated: use the Depth of config.Load, which honors the config files too
 func DepthSourceDetection() int {
 	var depth = os.Getenv("NOVM_DEPTH_SOURCE_DETECTION")
 	if depth == "" {
-		return 2
+		return -1
 	}
 	if n, err := strconv.Atoi(depth); err != nil {
-		return 2
+		return -1
 	} else {
 		return n
 	}
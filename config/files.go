@@ -0,0 +1,208 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/debdutdeb/novm/v3/pkg/sources"
+)
+
+// ProjectFile is the name of the project config file
+const ProjectFile = ".novmrc"
+
+// UserFile returns the path of the user config, $NOVM_WORKDIR/config.toml if
+// NOVM_WORKDIR is set, else novm/config.toml under $XDG_CONFIG_HOME
+// (~/.config). It doesn't have to exist.
+func UserFile() string {
+	if workdir := os.Getenv("NOVM_WORKDIR"); workdir != "" {
+		return filepath.Join(workdir, "config.toml")
+	}
+
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+
+		dir = filepath.Join(home, ".config")
+	}
+
+	return filepath.Join(dir, "novm", "config.toml")
+}
+
+// FindProject returns the path of the nearest .novmrc walking up from dir, the
+// same way version sources are searched for (see sources.Dirs), or "" if
+// there is none
+func FindProject(dir string, depth int) (string, error) {
+	for _, dir := range sources.Dirs(dir, depth) {
+		path := filepath.Join(dir, ProjectFile)
+
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		} else if !os.IsNotExist(err) {
+			return "", err
+		}
+	}
+
+	return "", nil
+}
+
+// readFile reads the config file at path into key/value pairs, sorted by
+// key. Lists are joined with commas, the env table becomes env.* keys.
+func readFile(path string) ([][2]string, error) {
+	var raw map[string]interface{}
+	if _, err := toml.DecodeFile(path, &raw); err != nil {
+		if os.IsNotExist(err) {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	var values [][2]string
+
+	for key, value := range raw {
+		if table, ok := value.(map[string]interface{}); ok && key+"." == envPrefix {
+			for name, v := range table {
+				values = append(values, [2]string{envPrefix + name, fmt.Sprint(v)})
+			}
+
+			continue
+		}
+
+		switch v := value.(type) {
+		case []interface{}:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+
+			values = append(values, [2]string{key, strings.Join(items, ",")})
+		case map[string]interface{}:
+			return nil, fmt.Errorf("%s: unknown setting %s", path, key)
+		default:
+			values = append(values, [2]string{key, fmt.Sprint(v)})
+		}
+	}
+
+	sort.Slice(values, func(i, j int) bool { return values[i][0] < values[j][0] })
+
+	return values, nil
+}
+
+// Set sets key to value in the config file at path, creating it if needed.
+// The file is rewritten, comments in it are lost.
+func Set(path, key, value string) error {
+	k, ok := lookup(key)
+	if !ok {
+		return fmt.Errorf("unknown setting %s", key)
+	}
+
+	if filepath.Base(path) == ProjectFile && !k.project {
+		return fmt.Errorf("%s can only be set in the user config", key)
+	}
+
+	// validate it the same way it would be read back
+	s, err := defaults()
+	if err != nil {
+		return err
+	}
+
+	if err := k.set(s, value); err != nil {
+		return fmt.Errorf("invalid %s %q: %w", key, value, err)
+	}
+
+	var typed interface{} = value
+
+	switch k.kind {
+	case kindInt:
+		typed, _ = strconv.Atoi(value)
+	case kindBool:
+		typed, _ = strconv.ParseBool(value)
+	case kindList:
+		list := []string{}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		typed = list
+	}
+
+	return update(path, func(raw map[string]interface{}) {
+		if name, ok := strings.CutPrefix(key, envPrefix); ok {
+			env, _ := raw["env"].(map[string]interface{})
+			if env == nil {
+				env = map[string]interface{}{}
+			}
+
+			env[name] = value
+			raw["env"] = env
+
+			return
+		}
+
+		raw[key] = typed
+	})
+}
+
+// Unset removes key from the config file at path
+func Unset(path, key string) error {
+	if _, ok := lookup(key); !ok {
+		return fmt.Errorf("unknown setting %s", key)
+	}
+
+	return update(path, func(raw map[string]interface{}) {
+		if name, ok := strings.CutPrefix(key, envPrefix); ok {
+			if env, ok := raw["env"].(map[string]interface{}); ok {
+				delete(env, name)
+
+				if len(env) == 0 {
+					delete(raw, "env")
+				}
+			}
+
+			return
+		}
+
+		delete(raw, key)
+	})
+}
+
+func update(path string, fn func(raw map[string]interface{})) error {
+	raw := map[string]interface{}{}
+
+	if _, err := toml.DecodeFile(path, &raw); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	fn(raw)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	if err := toml.NewEncoder(tmp).Encode(raw); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
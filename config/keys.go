@@ -0,0 +1,431 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/debdutdeb/novm/v3/pkg/n"
+	"github.com/debdutdeb/novm/v3/pkg/sources"
+)
+
+// DirName is the directory under $HOME novm keeps its files in by default
+const DirName = ".novm"
+
+// Update policies
+const (
+	// UpdateAuto checks for a new novm release and installs it
+	UpdateAuto = "auto"
+	// UpdateNotify checks for a new novm release and only says so
+	UpdateNotify = "notify"
+	// UpdateOff never checks
+	UpdateOff = "off"
+)
+
+// Resolution strategies, see n.Strategy
+var strategies = []string{"newest", "prefer-installed", "oldest-satisfying", "lts-preferred"}
+
+// Security policies, what to do when there is a security release newer than
+// the version in use
+const (
+	// SecurityWarn says so on every run
+	SecurityWarn = "warn"
+	// SecurityUpgrade uses the security release instead
+	SecurityUpgrade = "upgrade"
+	// SecurityOff does neither
+	SecurityOff = "off"
+)
+
+// End of life policies, what to do about versions whose release line is past
+// or close to its end of life
+const (
+	// EOLWarn warns in interactive sessions
+	EOLWarn = "warn"
+	// EOLAlways warns, in CI too
+	EOLAlways = "always"
+	// EOLError fails once the line is past its end of life, and warns before
+	EOLError = "error"
+	// EOLOff does nothing
+	EOLOff = "off"
+)
+
+// Index refresh modes, what to do once the release index is out of date
+const (
+	// RefreshBackground uses it as is and refreshes it in the background
+	RefreshBackground = "background"
+	// RefreshBlocking refreshes it before anything else
+	RefreshBlocking = "blocking"
+)
+
+// Log levels
+const (
+	LogInfo  = "info"
+	LogError = "error"
+)
+
+type kind int
+
+const (
+	kindString kind = iota
+	kindInt
+	kindBool
+	kindList
+)
+
+// setting is a key that can be set in a config file, with -c, and, if env
+// isn't empty, with an environment variable
+type setting struct {
+	key   string
+	kind  kind
+	env   string
+	usage string
+
+	// aliases are environment variables other tools use for the same thing,
+	// read in order when env isn't set
+	aliases []string
+
+	// project is whether a .novmrc may set it. Where novm is installed and
+	// downloads from are left to the user, a cloned repository shouldn't
+	// be able to change them.
+	project bool
+
+	// fromEnv, if set, converts the environment variable into a value
+	fromEnv func(value string) (string, error)
+
+	set func(s *Settings, value string) error
+	get func(s *Settings) string
+}
+
+// envPrefix is the prefix of the keys setting environment variables for
+// node, e.g. env.NODE_OPTIONS
+const envPrefix = "env."
+
+var keys = []setting{
+	{
+		key:   "root_dir",
+		env:   "NOVM_WORKDIR",
+		usage: "where novm installs nodejs and keeps its state (default ~/" + DirName + ")",
+		set:   func(s *Settings, v string) error { s.RootDir = v; return nil },
+		get:   func(s *Settings) string { return s.RootDir },
+	},
+	{
+		key:   "update",
+		env:   "NOVM_UPDATE",
+		usage: "what to do about new novm releases: auto, notify or off",
+		set: func(s *Settings, v string) error {
+			if v != UpdateAuto && v != UpdateNotify && v != UpdateOff {
+				return fmt.Errorf("expected one of %s, %s, %s", UpdateAuto, UpdateNotify, UpdateOff)
+			}
+
+			s.Update = v
+			return nil
+		},
+		get: func(s *Settings) string { return s.Update },
+	},
+	{
+		key:     "mirror",
+		kind:    kindList,
+		env:     "NOVM_MIRROR",
+		aliases: []string{"NVM_NODEJS_ORG_MIRROR", "NODE_MIRROR"},
+		usage:   "base URLs nodejs releases are downloaded from, tried in order, prerelease channels only from those ending in /release",
+		set: func(s *Settings, v string) (err error) {
+			if s.Mirrors, err = mirrors(v); err == nil && len(s.Mirrors) == 0 {
+				err = fmt.Errorf("expected at least one URL")
+			}
+			return
+		},
+		get: func(s *Settings) string { return strings.Join(s.Mirrors, ",") },
+	},
+	{
+		key:   "unofficial_mirror",
+		kind:  kindList,
+		env:   "NOVM_UNOFFICIAL_MIRROR",
+		usage: "base URLs of unofficial builds, used when there is no official one for this platform, empty to never use them",
+		set:   func(s *Settings, v string) (err error) { s.UnofficialMirrors, err = mirrors(v); return },
+		get:   func(s *Settings) string { return strings.Join(s.UnofficialMirrors, ",") },
+	},
+	{
+		key:   "schedule",
+		env:   "NOVM_SCHEDULE",
+		usage: "URL of the nodejs release schedule, used to warn about release lines close to or past their end of life",
+		set: func(s *Settings, v string) error {
+			if u, err := url.Parse(v); err != nil || u.Scheme == "" || u.Host == "" {
+				return fmt.Errorf("%q isn't a URL", v)
+			}
+
+			s.Schedule = v
+			return nil
+		},
+		get: func(s *Settings) string { return s.Schedule },
+	},
+	{
+		key:   "offline",
+		kind:  kindBool,
+		env:   "NOVM_OFFLINE",
+		usage: "only use installed versions, never fetch the release index",
+		set:   func(s *Settings, v string) (err error) { s.Offline, err = strconv.ParseBool(v); return },
+		get:   func(s *Settings) string { return strconv.FormatBool(s.Offline) },
+	},
+	{
+		key:   "index_ttl",
+		env:   "NOVM_INDEX_TTL",
+		usage: "how old the release index can get before it is refreshed, e.g. 24h or 30m, 0 refreshes it every run",
+		set: func(s *Settings, v string) error {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return fmt.Errorf("expected a duration, e.g. 24h")
+			}
+
+			if d < 0 {
+				return fmt.Errorf("can't be negative")
+			}
+
+			s.IndexTTL = v
+			return nil
+		},
+		get: func(s *Settings) string { return s.IndexTTL },
+	},
+	{
+		key:   "index_refresh",
+		env:   "NOVM_INDEX_REFRESH",
+		usage: "what to do about an out of date release index: " + RefreshBackground + " uses it and refreshes it in the background, " + RefreshBlocking + " waits for it",
+		set: func(s *Settings, v string) error {
+			if v != RefreshBackground && v != RefreshBlocking {
+				return fmt.Errorf("expected one of %s, %s", RefreshBackground, RefreshBlocking)
+			}
+
+			s.IndexRefresh = v
+			return nil
+		},
+		get: func(s *Settings) string { return s.IndexRefresh },
+	},
+	{
+		key:   "retention_days",
+		kind:  kindInt,
+		env:   "NOVM_RETENTION_DAYS",
+		usage: "days a rarely used version is kept after its last use, 0 keeps them forever",
+		set: func(s *Settings, v string) (err error) {
+			if s.RetentionDays, err = strconv.Atoi(v); err == nil && s.RetentionDays < 0 {
+				err = fmt.Errorf("can't be negative")
+			}
+			return
+		},
+		get: func(s *Settings) string { return strconv.Itoa(s.RetentionDays) },
+	},
+	{
+		key:   "log_level",
+		env:   "NOVM_LOG_LEVEL",
+		usage: "info, or error to only print errors",
+		set: func(s *Settings, v string) error {
+			if v != LogInfo && v != LogError {
+				return fmt.Errorf("expected one of %s, %s", LogInfo, LogError)
+			}
+
+			s.LogLevel = v
+			return nil
+		},
+		get: func(s *Settings) string { return s.LogLevel },
+	},
+	{
+		key:   "depth",
+		kind:  kindInt,
+		env:   "NOVM_DEPTH_SOURCE_DETECTION",
+		usage: "caps how many parent directories are searched for a version, -1 stops at the repository root",
+		set:   func(s *Settings, v string) (err error) { s.Depth, err = strconv.Atoi(v); return },
+		get:   func(s *Settings) string { return strconv.Itoa(s.Depth) },
+	},
+	{
+		key:   "experimental",
+		kind:  kindBool,
+		env:   "NOVM_NO_EXPERIMENTAL",
+		usage: "whether experimental version sources are used",
+		fromEnv: func(v string) (string, error) {
+			off, err := strconv.ParseBool(v)
+			return strconv.FormatBool(!off), err
+		},
+		set: func(s *Settings, v string) (err error) { s.Experimental, err = strconv.ParseBool(v); return },
+		get: func(s *Settings) string { return strconv.FormatBool(s.Experimental) },
+	},
+	{
+		key:     "sources",
+		kind:    kindList,
+		project: true,
+		usage:   "sources consulted first, in this order, the rest follow in their usual order",
+		set: func(s *Settings, v string) (err error) {
+			s.order, err = sourceNames(v)
+			return
+		},
+		get: func(s *Settings) string { return strings.Join(s.Sources, ",") },
+	},
+	{
+		key:     "disable",
+		kind:    kindList,
+		project: true,
+		usage:   "sources never consulted",
+		set: func(s *Settings, v string) (err error) {
+			s.Disabled, err = sourceNames(v)
+			return
+		},
+		get: func(s *Settings) string { return strings.Join(s.Disabled, ",") },
+	},
+	{
+		key:     "strategy",
+		env:     "NOVM_STRATEGY",
+		project: true,
+		usage:   "which release satisfying a version range is used: " + strings.Join(strategies, ", "),
+		set: func(s *Settings, v string) error {
+			for _, known := range strategies {
+				if v == known {
+					s.Strategy = v
+					return nil
+				}
+			}
+
+			return fmt.Errorf("expected one of %s", strings.Join(strategies, ", "))
+		},
+		get: func(s *Settings) string { return s.Strategy },
+	},
+	{
+		key:     "security",
+		env:     "NOVM_SECURITY",
+		project: true,
+		usage:   "when a newer security release of the version's major line is out: warn, upgrade within its minor line, or off",
+		set: func(s *Settings, v string) error {
+			if v != SecurityWarn && v != SecurityUpgrade && v != SecurityOff {
+				return fmt.Errorf("expected one of %s, %s, %s", SecurityWarn, SecurityUpgrade, SecurityOff)
+			}
+
+			s.Security = v
+			return nil
+		},
+		get: func(s *Settings) string { return s.Security },
+	},
+	{
+		key:     "eol",
+		env:     "NOVM_EOL",
+		project: true,
+		usage:   "when the version's release line is past or close to its end of life: warn (interactive only), always warn, error, or off",
+		set: func(s *Settings, v string) error {
+			if v != EOLWarn && v != EOLAlways && v != EOLError && v != EOLOff {
+				return fmt.Errorf("expected one of %s, %s, %s, %s", EOLWarn, EOLAlways, EOLError, EOLOff)
+			}
+
+			s.EOL = v
+			return nil
+		},
+		get: func(s *Settings) string { return s.EOL },
+	},
+	{
+		key:     "eol_days",
+		kind:    kindInt,
+		env:     "NOVM_EOL_DAYS",
+		project: true,
+		usage:   "days before the end of life of a release line to start warning about it",
+		set: func(s *Settings, v string) (err error) {
+			if s.EOLDays, err = strconv.Atoi(v); err == nil && s.EOLDays < 0 {
+				err = fmt.Errorf("can't be negative")
+			}
+			return
+		},
+		get: func(s *Settings) string { return strconv.Itoa(s.EOLDays) },
+	},
+	{
+		key:     "auto_install",
+		kind:    kindBool,
+		project: true,
+		usage:   "download versions that aren't installed, when false novm fails instead",
+		set:     func(s *Settings, v string) (err error) { s.AutoInstall, err = strconv.ParseBool(v); return },
+		get:     func(s *Settings) string { return strconv.FormatBool(s.AutoInstall) },
+	},
+}
+
+func lookup(key string) (setting, bool) {
+	if name, ok := strings.CutPrefix(key, envPrefix); ok && name != "" {
+		return setting{
+			key:     key,
+			project: true,
+			usage:   "set for node and everything it runs",
+			set:     func(s *Settings, v string) error { s.Env[name] = v; return nil },
+			get:     func(s *Settings) string { return s.Env[name] },
+		}, true
+	}
+
+	for _, k := range keys {
+		if k.key == key {
+			return k, true
+		}
+	}
+
+	return setting{}, false
+}
+
+func sourceNames(list string) ([]string, error) {
+	var names []string
+
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+
+		if _, ok := sources.Default.Lookup(name); !ok {
+			return nil, fmt.Errorf("unknown source %q", name)
+		}
+
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+// mirrors parses a comma separated list of base URLs
+func mirrors(list string) ([]string, error) {
+	var urls []string
+
+	for _, mirror := range strings.Split(list, ",") {
+		if mirror = strings.TrimSuffix(strings.TrimSpace(mirror), "/"); mirror == "" {
+			continue
+		}
+
+		if u, err := url.Parse(mirror); err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("%q isn't a URL", mirror)
+		}
+
+		urls = append(urls, mirror)
+	}
+
+	return urls, nil
+}
+
+func defaults() (*Settings, error) {
+	u, err := user.Current()
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect current user: %w", err)
+	}
+
+	return &Settings{
+		RootDir: filepath.Join(u.HomeDir, DirName),
+		Update:  UpdateAuto,
+		Mirrors: []string{n.DefaultMirror},
+
+		UnofficialMirrors: []string{n.DefaultUnofficialMirror},
+		Schedule:          n.DefaultSchedule,
+
+		IndexTTL:      "24h",
+		IndexRefresh:  RefreshBackground,
+		RetentionDays: 10,
+		LogLevel:      LogInfo,
+		Depth:         -1,
+		Experimental:  true,
+		Strategy:      "newest",
+		Security:      SecurityWarn,
+		EOL:           EOLWarn,
+		EOLDays:       90,
+		AutoInstall:   true,
+		Env:           map[string]string{},
+		Origins:       map[string]string{},
+	}, nil
+}
@@ -0,0 +1,335 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/debdutdeb/novm/v3/pkg/sources"
+)
+
+// Settings are what novm runs with in a directory. They come in layers, each
+// overriding the ones before it: the defaults, the user config, the
+// project's .novmrc, environment variables, and flags (novm -c key=value).
+type Settings struct {
+	RootDir string   `json:"root_dir"`
+	Update  string   `json:"update"`
+	Mirrors []string `json:"mirror"`
+
+	// UnofficialMirrors are used for platforms without official builds,
+	// e.g. musl, none turns that off
+	UnofficialMirrors []string `json:"unofficial_mirror"`
+
+	// Schedule is where the release schedule is downloaded from
+	Schedule string `json:"schedule"`
+
+	Offline bool `json:"offline"`
+
+	// IndexTTL is how old the release index can get, see IndexMaxAge, and
+	// IndexRefresh whether it is refreshed in the background once it is
+	// older
+	IndexTTL     string `json:"index_ttl"`
+	IndexRefresh string `json:"index_refresh"`
+
+	RetentionDays int    `json:"retention_days"`
+	LogLevel      string `json:"log_level"`
+
+	// Depth is how many parent directories are searched for a version
+	Depth int `json:"depth"`
+
+	// Experimental is false when NOVM_NO_EXPERIMENTAL is set
+	Experimental bool `json:"experimental"`
+
+	// Sources are the enabled sources in the order they are consulted
+	Sources []string `json:"sources"`
+
+	// Disabled are the sources turned off
+	Disabled []string `json:"disabled"`
+
+	Env map[string]string `json:"env"`
+
+	// Strategy picks among the releases satisfying a version range
+	Strategy string `json:"strategy"`
+
+	// Security is what to do about versions with a newer security release
+	Security string `json:"security"`
+
+	// EOL is what to do about versions whose release line is past, or
+	// within EOLDays of, its end of life
+	EOL     string `json:"eol"`
+	EOLDays int    `json:"eol_days"`
+
+	AutoInstall bool `json:"auto_install"`
+
+	// User and Project are the config files in effect, empty if there are
+	// none
+	User    string `json:"user"`
+	Project string `json:"project"`
+
+	// Origins says where each setting that isn't a default came from, by key
+	Origins map[string]string `json:"origins"`
+
+	// order is the sources setting, Sources is what it results in
+	order []string
+
+	registry *sources.Registry
+}
+
+type layer struct {
+	origin string
+
+	// project is set for .novmrc layers, which can't set every key
+	project bool
+
+	// lenient layers skip invalid values with a warning instead of failing,
+	// a stray environment variable mustn't break every node and npm run
+	lenient bool
+
+	values [][2]string
+}
+
+// Load returns the settings for dir. An empty dir skips looking for a
+// .novmrc, only the user config and the environment are read. overrides are
+// key=value pairs that take precedence over everything else.
+func Load(dir string, overrides ...string) (*Settings, error) {
+	s, err := defaults()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		user    *layer
+		project *layer
+		env     = envLayer()
+		flags   = &layer{origin: "flag"}
+	)
+
+	for _, o := range overrides {
+		key, value, ok := strings.Cut(o, "=")
+		if !ok {
+			return nil, fmt.Errorf("expected key=value, got %q", o)
+		}
+
+		flags.values = append(flags.values, [2]string{key, value})
+	}
+
+	if s.User = UserFile(); s.User != "" {
+		values, err := readFile(s.User)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		if err == nil {
+			user = &layer{origin: s.User, values: values}
+		} else {
+			s.User = ""
+		}
+	}
+
+	if dir != "" {
+		// where to look for the .novmrc doesn't depend on the .novmrc
+		if err := s.apply(user, env, flags); err != nil {
+			return nil, err
+		}
+
+		if s.Project, err = FindProject(dir, s.Depth); err != nil {
+			return nil, err
+		}
+
+		if s.Project != "" {
+			values, err := readFile(s.Project)
+			if err != nil {
+				return nil, err
+			}
+
+			project = &layer{origin: s.Project, project: true, values: values}
+		}
+	}
+
+	if err := s.apply(user, project, env, flags); err != nil {
+		return nil, err
+	}
+
+	if !s.Experimental {
+		for _, source := range sources.Default.Sources() {
+			if sources.IsExperimental(source.Name()) {
+				s.Disabled = append(s.Disabled, source.Name())
+			}
+		}
+	}
+
+	s.Disabled = unique(s.Disabled)
+
+	s.registry = sources.Default.Ordered(s.order...).Without(s.Disabled...)
+
+	s.Sources = nil
+	for _, source := range s.registry.Sources() {
+		s.Sources = append(s.Sources, source.Name())
+	}
+
+	return s, nil
+}
+
+func (s *Settings) apply(layers ...*layer) error {
+	for _, l := range layers {
+		if l == nil {
+			continue
+		}
+
+		values := l.values[:0]
+
+		for _, kv := range l.values {
+			k, ok := lookup(kv[0])
+			if !ok {
+				return fmt.Errorf("%s: unknown setting %s", l.origin, kv[0])
+			}
+
+			if l.project && !k.project {
+				return fmt.Errorf("%s: %s can only be set in the user config", l.origin, k.key)
+			}
+
+			if err := k.set(s, kv[1]); err != nil {
+				err = fmt.Errorf("%s: invalid %s %q: %w", l.origin, k.key, kv[1], err)
+				if !l.lenient {
+					return err
+				}
+
+				// dropped so applying the layer again doesn't warn again
+				log.Printf("%v, ignored", err)
+				continue
+			}
+
+			values = append(values, kv)
+			s.Origins[k.key] = l.origin
+		}
+
+		l.values = values
+	}
+
+	return nil
+}
+
+func envLayer() *layer {
+	l := &layer{origin: "environment", lenient: true}
+
+	for _, k := range keys {
+		if k.env == "" {
+			continue
+		}
+
+		value := os.Getenv(k.env)
+		for _, alias := range k.aliases {
+			if value != "" {
+				break
+			}
+
+			value = os.Getenv(alias)
+		}
+
+		if value == "" {
+			continue
+		}
+
+		if k.fromEnv != nil {
+			v, err := k.fromEnv(value)
+			if err != nil {
+				continue
+			}
+
+			value = v
+		}
+
+		l.values = append(l.values, [2]string{k.key, value})
+	}
+
+	return l
+}
+
+// Registry returns the sources to detect a version with
+func (s *Settings) Registry() *sources.Registry {
+	return s.registry
+}
+
+// Retention is how long a rarely used version is kept after its last use,
+// zero keeps them forever
+func (s *Settings) Retention() time.Duration {
+	return time.Duration(s.RetentionDays) * 24 * time.Hour
+}
+
+// IndexMaxAge is IndexTTL parsed
+func (s *Settings) IndexMaxAge() time.Duration {
+	d, _ := time.ParseDuration(s.IndexTTL)
+	return d
+}
+
+// EnvKeys returns the keys of Env, sorted
+func (s *Settings) EnvKeys() []string {
+	keys := make([]string, 0, len(s.Env))
+	for key := range s.Env {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Get returns the value of key, lists are comma separated
+func (s *Settings) Get(key string) (string, error) {
+	k, ok := lookup(key)
+	if !ok {
+		return "", fmt.Errorf("unknown setting %s", key)
+	}
+
+	return k.get(s), nil
+}
+
+// Origin returns where key was set, "default" if it wasn't
+func (s *Settings) Origin(key string) string {
+	if origin, ok := s.Origins[key]; ok {
+		return origin
+	}
+
+	return "default"
+}
+
+// Keys returns every setting, env.* ones included
+func (s *Settings) Keys() []string {
+	list := make([]string, 0, len(keys)+len(s.Env))
+	for _, k := range keys {
+		list = append(list, k.key)
+	}
+
+	for _, name := range s.EnvKeys() {
+		list = append(list, envPrefix+name)
+	}
+
+	return list
+}
+
+// Usage describes key
+func Usage(key string) string {
+	k, _ := lookup(key)
+	if k.env != "" {
+		return k.usage + " (" + k.env + ")"
+	}
+
+	return k.usage
+}
+
+func unique(values []string) []string {
+	seen := map[string]bool{}
+
+	var u []string
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			u = append(u, v)
+		}
+	}
+
+	return u
+}
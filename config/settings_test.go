@@ -0,0 +1,223 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadProject(t *testing.T) {
+	t.Setenv("NOVM_WORKDIR", t.TempDir())
+	t.Setenv("NOVM_DEPTH_SOURCE_DETECTION", "")
+	t.Setenv("NOVM_NO_EXPERIMENTAL", "")
+
+	root := t.TempDir()
+	dir := filepath.Join(root, "packages", "app")
+	if err := os.MkdirAll(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	rc := `sources = [".nvmrc", "package.json"]
+disable = ["Dockerfile"]
+auto_install = false
+strategy = "prefer-installed"
+
+[env]
+NODE_OPTIONS = "--max-old-space-size=4096"
+`
+	if err := os.WriteFile(filepath.Join(root, ProjectFile), []byte(rc), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Project != filepath.Join(root, ProjectFile) {
+		t.Fatalf("expected the repository's %s, got %q", ProjectFile, s.Project)
+	}
+
+	if s.AutoInstall || s.Strategy != "prefer-installed" || s.Env["NODE_OPTIONS"] != "--max-old-space-size=4096" {
+		t.Fatalf("project settings not applied: %+v", s)
+	}
+
+	order := strings.Join(s.Sources, " ")
+	if !strings.HasPrefix(order, ".nvmrc package.json environment") || strings.Contains(order, "Dockerfile") {
+		t.Fatalf("unexpected source order: %s", order)
+	}
+
+	t.Setenv("NOVM_NO_EXPERIMENTAL", "1")
+
+	if s, err = Load(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(strings.Join(s.Sources, " "), ".github/workflows") {
+		t.Fatalf("expected experimental sources to be disabled, got %v", s.Sources)
+	}
+}
+
+func TestProjectRejects(t *testing.T) {
+	t.Setenv("NOVM_WORKDIR", t.TempDir())
+
+	for _, rc := range []string{`auto_instal = false`, `disable = ["nope"]`, `mirror = "https://example.com"`, `strategy = "fastest"`, `security = "ignore"`, `eol = "sometimes"`} {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, ProjectFile), []byte(rc), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := Load(dir); err == nil {
+			t.Fatalf("expected %q to be rejected", rc)
+		}
+	}
+}
+
+func TestPrecedence(t *testing.T) {
+	workdir := t.TempDir()
+	t.Setenv("NOVM_WORKDIR", workdir)
+	t.Setenv("NOVM_LOG_LEVEL", "")
+	t.Setenv("NOVM_RETENTION_DAYS", "")
+
+	user := "log_level = \"error\"\nretention_days = 3\nauto_install = false\n\n[env]\nA = \"user\"\nB = \"user\"\n"
+	if err := os.WriteFile(UserFile(), []byte(user), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ProjectFile), []byte("auto_install = true\n[env]\nB = \"project\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.RootDir != workdir || s.LogLevel != LogError || s.RetentionDays != 3 {
+		t.Fatalf("user config not applied: %+v", s)
+	}
+
+	if !s.AutoInstall || s.Env["A"] != "user" || s.Env["B"] != "project" {
+		t.Fatalf("expected the project config to win over the user config: %+v", s)
+	}
+
+	t.Setenv("NOVM_RETENTION_DAYS", "5")
+	t.Setenv("NOVM_LOG_LEVEL", "info")
+
+	if s, err = Load(dir, "log_level=error", "env.A=flag"); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.RetentionDays != 5 || s.Origin("retention_days") != "environment" {
+		t.Fatalf("expected the environment to win over the user config, got %d from %s", s.RetentionDays, s.Origin("retention_days"))
+	}
+
+	if s.LogLevel != LogError || s.Env["A"] != "flag" || s.Origin("log_level") != "flag" {
+		t.Fatalf("expected flags to win over everything: %+v", s)
+	}
+
+	if s.Origin("update") != "default" {
+		t.Fatalf("expected update to be a default, got %s", s.Origin("update"))
+	}
+
+	// an invalid variable is skipped, not fatal
+	t.Setenv("NOVM_RETENTION_DAYS", "abc")
+
+	if s, err = Load(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.RetentionDays != 3 || s.Origin("retention_days") != UserFile() {
+		t.Fatalf("expected the user config's retention_days, got %d from %s", s.RetentionDays, s.Origin("retention_days"))
+	}
+
+	// flags aren't
+	if _, err := Load(dir, "retention_days=abc"); err == nil {
+		t.Fatal("expected an invalid flag to fail")
+	}
+}
+
+func TestMirror(t *testing.T) {
+	t.Setenv("NOVM_WORKDIR", t.TempDir())
+	t.Setenv("NOVM_MIRROR", "")
+	t.Setenv("NVM_NODEJS_ORG_MIRROR", "")
+	t.Setenv("NODE_MIRROR", "https://npmmirror.com/mirrors/node/")
+
+	s, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(s.Mirrors) != 1 || s.Mirrors[0] != "https://npmmirror.com/mirrors/node" {
+		t.Fatalf("expected NODE_MIRROR to be read, got %v", s.Mirrors)
+	}
+
+	t.Setenv("NVM_NODEJS_ORG_MIRROR", "https://nvm.example.com")
+
+	if s, err = Load(""); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Mirrors[0] != "https://nvm.example.com" {
+		t.Fatalf("expected NVM_NODEJS_ORG_MIRROR to win over NODE_MIRROR, got %v", s.Mirrors)
+	}
+
+	t.Setenv("NOVM_MIRROR", "https://a.example.com, https://b.example.com")
+
+	if s, err = Load(""); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Join(s.Mirrors, " "); got != "https://a.example.com https://b.example.com" {
+		t.Fatalf("expected the NOVM_MIRROR list, got %s", got)
+	}
+
+	if _, err := Load("", "mirror=not a url"); err == nil {
+		t.Fatal("expected an invalid mirror to be rejected")
+	}
+
+	if s, err = Load("", "unofficial_mirror="); err != nil || len(s.UnofficialMirrors) != 0 {
+		t.Fatalf("expected unofficial builds to be turned off, got %v (%v)", s.UnofficialMirrors, err)
+	}
+}
+
+func TestSetUnset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "novm", "config.toml")
+
+	for key, value := range map[string]string{"retention_days": "3", "experimental": "false", "disable": "Dockerfile, .nvmrc", "env.NODE_OPTIONS": "--inspect"} {
+		if err := Set(path, key, value); err != nil {
+			t.Fatalf("%s: %v", key, err)
+		}
+	}
+
+	for key, value := range map[string]string{"retention_days": "x", "update": "sometimes", "index_ttl": "-1h", "index_refresh": "later", "nope": "1"} {
+		if err := Set(path, key, value); err == nil {
+			t.Fatalf("expected %s=%s to be rejected", key, value)
+		}
+	}
+
+	if err := Unset(path, "experimental"); err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := readFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := [][2]string{{"disable", "Dockerfile,.nvmrc"}, {"env.NODE_OPTIONS", "--inspect"}, {"retention_days", "3"}}
+	if len(values) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, values)
+	}
+
+	for i := range expected {
+		if values[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, values)
+		}
+	}
+}
@@ -3,12 +3,14 @@ module github.com/debdutdeb/novm/v3
 go 1.25.0
 
 require (
+	github.com/BurntSushi/toml v1.5.0
 	github.com/Masterminds/semver/v3 v3.2.1
 	github.com/debdutdeb/gopark v0.0.0-20260427071909-043a49ed29bf
 	github.com/spf13/cobra v1.8.0
 	golang.org/x/mod v0.14.0
 	golang.org/x/sync v0.22.0
 	golang.org/x/sys v0.16.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -4,12 +4,14 @@ import (
 	"os"
 
 	"github.com/debdutdeb/novm/v3/commands"
+	"github.com/debdutdeb/novm/v3/config"
 	"github.com/debdutdeb/novm/v3/internal/log"
 	"github.com/debdutdeb/novm/v3/utils"
+	"github.com/debdutdeb/novm/v3/utils/tty"
 )
 
 func main() {
-	if !utils.IsInteractive() {
+	if !tty.IsInteractive() {
 		if err := commands.Run(); err != nil {
 			log.Fatal(err)
 		}
@@ -20,7 +22,19 @@ func main() {
 		log.Fatal("failed to run fresh install tasks: ", err)
 	}
 
-	if err := wrapInUpdateCheck(commands.Run); err != nil {
+	settings, err := config.Load("")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if settings.Update == config.UpdateOff {
+		if err := commands.Run(); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if err := wrapInUpdateCheck(commands.Run, settings.Update); err != nil {
 		log.Fatal(err)
 		os.Exit(1)
 	}
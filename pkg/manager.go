@@ -16,7 +16,7 @@ import (
 
 	semverv3 "github.com/Masterminds/semver/v3"
 	gopark "github.com/debdutdeb/gopark/pkg/utils"
-	"github.com/debdutdeb/novm/v3/utils"
+	"github.com/debdutdeb/novm/v3/utils/tty"
 )
 
 // Deprecated: use package /v3/pkg/n instead
@@ -328,7 +328,7 @@ func (n *N) Install() error {
 
 	archivePath := filepath.Join(tmpDir, filename)
 
-	if utils.IsInteractive() {
+	if tty.IsInteractive() {
 		err = gopark.DownloadWithProgressBar("Node "+n.versionStr, url, archivePath)
 		if err != nil {
 			return err
@@ -0,0 +1,233 @@
+package n
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	semverv3 "github.com/Masterminds/semver/v3"
+)
+
+// DefaultAlias is the alias holding the default version, the one used when
+// no source has a version
+const DefaultAlias = "default"
+
+// ErrAliasNotFound is returned when removing an alias that isn't set
+var ErrAliasNotFound = errors.New("alias not found")
+
+// ErrAliasCycle is returned for aliases that end up pointing back to
+// themselves
+var ErrAliasCycle = errors.New("alias cycle")
+
+var aliasName = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9._-]*$`)
+
+// keywords are the specs resolve gives a meaning to, aliases can't take
+// their names
+var keywords = []string{"latest", "node", "stable", "lts", "system"}
+
+// aliasDir is where aliases are kept, a file per alias holding its target,
+// like nvm does
+func aliasDir(rootDir string) string {
+	return filepath.Join(rootDir, "alias")
+}
+
+// validAlias returns an error if name can't be an alias, because it isn't
+// a plain name or because it already means something as a spec
+func validAlias(name string) error {
+	if !aliasName.MatchString(name) {
+		return fmt.Errorf("invalid alias name %q, use letters, digits, '.', '_' and '-', starting with a letter", name)
+	}
+
+	lower := strings.ToLower(name)
+
+	if slices.Contains(keywords, lower) || slices.Contains(Channels, lower) || strings.HasPrefix(lower, "lts-") {
+		return fmt.Errorf("%q is a reserved name", name)
+	}
+
+	if _, err := semverv3.NewVersion(name); err == nil {
+		return fmt.Errorf("%q is a version, not a name", name)
+	}
+
+	if _, err := parseRange(name, true); err == nil {
+		return fmt.Errorf("%q is a version range, not a name", name)
+	}
+
+	return nil
+}
+
+// Aliases returns the aliases set under rootDir, by name
+func Aliases(rootDir string) (map[string]string, error) {
+	entries, err := os.ReadDir(aliasDir(rootDir))
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	aliases := make(map[string]string, len(entries))
+
+	for _, entry := range entries {
+		if entry.IsDir() || validAlias(entry.Name()) != nil {
+			continue
+		}
+
+		target, ok, err := readAlias(rootDir, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			aliases[entry.Name()] = target
+		}
+	}
+
+	return aliases, nil
+}
+
+// SetAlias points name at target, a version spec or another alias. Aliases
+// that would lead back to name are refused, and so are the codenames of the
+// lts lines in the release index, an alias would hide the line. opts are
+// those of NewNodeManager, for reading the index, which is fetched if it
+// isn't cached. Without it, only DefaultAlias can be set.
+func SetAlias(rootDir, name, target string, opts ...Option) error {
+	if err := validAlias(name); err != nil {
+		return err
+	}
+
+	if name != DefaultAlias {
+		lts, err := isLtsLine(rootDir, name, opts...)
+		if err != nil {
+			return err
+		}
+
+		if lts {
+			return fmt.Errorf("%q is the codename of an lts line", name)
+		}
+	}
+
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return fmt.Errorf("alias %s needs a target", name)
+	}
+
+	_, _, err := expandAlias(name, func(spec string) (string, bool, error) {
+		if spec == name {
+			return target, true, nil
+		}
+
+		return readAlias(rootDir, spec)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(aliasDir(rootDir), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(aliasDir(rootDir), name), []byte(target+"\n"), 0644)
+}
+
+// RemoveAlias removes the alias name, aliases pointing at it are left as
+// they are
+func RemoveAlias(rootDir, name string) error {
+	if err := validAlias(name); err != nil {
+		return err
+	}
+
+	err := os.Remove(filepath.Join(aliasDir(rootDir), name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %s", ErrAliasNotFound, name)
+	}
+
+	return err
+}
+
+// isLtsLine reports whether name is the codename of an lts line in the
+// release index under rootDir, refreshing it first if it is older than the
+// max age, a line may have been named since. Without an index it can't
+// tell, which fails with ErrOffline.
+func isLtsLine(rootDir, name string, opts ...Option) (bool, error) {
+	r := NewResolver(rootDir, opts...)
+	n := r.newN("")
+
+	if _, err := r.index(n); err != nil {
+		return false, err
+	}
+
+	content, err := os.ReadFile(n.cacheFile())
+	if err != nil {
+		return false, fmt.Errorf("%w: can't tell if %q is the codename of an lts line without the release index", ErrOffline, name)
+	}
+
+	var cache nCache
+	if err := json.Unmarshal(content, &cache); err != nil {
+		return false, err
+	}
+
+	return cache.hasLtsLine(strings.ToLower(name)), nil
+}
+
+// Default returns what the default version is set to, ok is false if it
+// isn't set
+func Default(rootDir string) (spec string, ok bool, err error) {
+	return readAlias(rootDir, DefaultAlias)
+}
+
+// ExpandAlias follows spec through the aliases under rootDir, returning the
+// spec it ends up at and the aliases on the way, spec itself first. A spec
+// that isn't an alias is returned as is.
+func ExpandAlias(rootDir, spec string) (string, []string, error) {
+	return expandAlias(spec, func(spec string) (string, bool, error) {
+		return readAlias(rootDir, spec)
+	})
+}
+
+func expandAlias(spec string, lookup func(string) (string, bool, error)) (string, []string, error) {
+	var chain []string
+
+	for {
+		target, ok, err := lookup(spec)
+		if err != nil {
+			return "", nil, err
+		}
+
+		if !ok {
+			return spec, chain, nil
+		}
+
+		if slices.Contains(chain, spec) {
+			return "", nil, fmt.Errorf("%w: %s -> %s", ErrAliasCycle, strings.Join(chain, " -> "), spec)
+		}
+
+		chain = append(chain, spec)
+		spec = target
+	}
+}
+
+// readAlias returns the target of the alias name, ok is false if there is
+// no such alias
+func readAlias(rootDir, name string) (target string, ok bool, err error) {
+	if validAlias(name) != nil {
+		return "", false, nil
+	}
+
+	content, err := os.ReadFile(filepath.Join(aliasDir(rootDir), name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", false, nil
+	}
+
+	if err != nil {
+		return "", false, err
+	}
+
+	return strings.TrimSpace(string(content)), true, nil
+}
@@ -0,0 +1,101 @@
+package n
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAlias(t *testing.T) {
+	root := t.TempDir()
+	writeCache(t, root)
+
+	for name, target := range map[string]string{"legacy-api": "^18", "work": "legacy-api", "default": "lts", "next": "latest"} {
+		if err := SetAlias(root, name, target); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+
+	if spec, ok, err := Default(root); err != nil || !ok || spec != "lts" {
+		t.Fatalf("expected default lts, got %q (%v, %v)", spec, ok, err)
+	}
+
+	aliases, err := Aliases(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(aliases) != 4 || aliases["work"] != "legacy-api" {
+		t.Fatalf("unexpected aliases %v", aliases)
+	}
+
+	r := NewResolver(root, WithMirror("http://127.0.0.1:1"))
+
+	for spec, expected := range map[string]string{"work": "v18.20.4", "legacy-api": "v18.20.4", "default": "v22.11.0", "next": "v23.1.0"} {
+		n, err := r.resolve(spec)
+		if err != nil {
+			t.Fatalf("%s: %v", spec, err)
+		}
+
+		if n.versionStr != expected {
+			t.Errorf("%s: expected %s, got %s", spec, expected, n.versionStr)
+		}
+	}
+
+	n, err := r.resolve("work")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []string{"work", "legacy-api", "^18"}; !reflect.DeepEqual(n.Resolution().Aliases, expected) {
+		t.Fatalf("expected aliases %v, got %v", expected, n.Resolution().Aliases)
+	}
+
+	// legacy-api -> work -> legacy-api
+	if err := SetAlias(root, "legacy-api", "work"); !errors.Is(err, ErrAliasCycle) {
+		t.Fatalf("expected a cycle, got %v", err)
+	}
+
+	if err := SetAlias(root, "loop", "loop"); !errors.Is(err, ErrAliasCycle) {
+		t.Fatalf("expected a cycle, got %v", err)
+	}
+
+	// edited by hand
+	if err := os.WriteFile(filepath.Join(aliasDir(root), "legacy-api"), []byte("work\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Resolve("work"); !errors.Is(err, ErrAliasCycle) {
+		t.Fatalf("expected a cycle, got %v", err)
+	}
+
+	for _, name := range []string{"lts", "latest", "Node", "rc", "lts-1", "18", "v20.1", "x", "18.x", "../work", "lts/iron", "", "iron", "Hydrogen"} {
+		if err := SetAlias(root, name, "20"); err == nil {
+			t.Errorf("%q: expected an invalid name", name)
+		}
+	}
+
+	if err := RemoveAlias(root, "work"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RemoveAlias(root, "work"); !errors.Is(err, ErrAliasNotFound) {
+		t.Fatalf("expected ErrAliasNotFound, got %v", err)
+	}
+}
+
+func TestAliasWithoutIndex(t *testing.T) {
+	root := t.TempDir()
+	unreachable := WithMirror("http://127.0.0.1:1")
+
+	// any name may be an lts line, nothing says it isn't
+	if err := SetAlias(root, "work", "20", unreachable); !errors.Is(err, ErrOffline) {
+		t.Fatalf("expected ErrOffline, got %v", err)
+	}
+
+	if err := SetAlias(root, DefaultAlias, "20", unreachable); err != nil {
+		t.Fatal(err)
+	}
+}
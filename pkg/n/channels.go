@@ -0,0 +1,103 @@
+package n
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	semverv3 "github.com/Masterminds/semver/v3"
+)
+
+// Channels are the prerelease channels a version spec can pick, as
+// "<channel>" for its newest build or "<channel>/<version>", e.g. rc/22
+var Channels = []string{"nightly", "rc", "v8-canary"}
+
+// parseChannel splits spec into a channel and the version within it. A
+// channel alone means its newest build, a major or major.minor the newest
+// build of that line, like in .nvmrc, which parseVersion already takes as a
+// range.
+func parseChannel(spec string) (channel, version string, ok bool) {
+	name, version, _ := strings.Cut(spec, "/")
+
+	for _, channel := range Channels {
+		if !strings.EqualFold(name, channel) {
+			continue
+		}
+
+		if version == "" {
+			return channel, "latest", true
+		}
+
+		return channel, version, true
+	}
+
+	return "", spec, false
+}
+
+// Channel returns the channel the version was resolved in, empty for
+// releases
+func (n *N) Channel() string {
+	return n.channel
+}
+
+// channelMirrors are where the channel's index and builds are downloaded
+// from, the channel's tree next to each mirror's release tree. Mirrors
+// without one, i.e. not ending in /release, are left out, and it's an error
+// if none is left.
+func channelMirrors(mirrors []string, channel string) ([]string, error) {
+	var list []string
+
+	for _, mirror := range mirrors {
+		if base, ok := strings.CutSuffix(strings.TrimSuffix(mirror, "/"), "/release"); ok {
+			list = append(list, base+"/"+channel)
+		}
+	}
+
+	if len(list) == 0 {
+		return nil, fmt.Errorf("no mirror has a %s tree next to its release tree, expected one ending in /release, got %s", channel, strings.Join(mirrors, ", "))
+	}
+
+	return list, nil
+}
+
+// versionsDir is where versions are installed. Builds from channels go to
+// their own directory, so they aren't taken for releases, e.g. by
+// common.ListVersions.
+func (n *N) versionsDir() string {
+	if n.channel != "" {
+		return filepath.Join(n.rootDir, "channels", n.channel)
+	}
+
+	return filepath.Join(n.rootDir, "versions")
+}
+
+// cacheFile is where the release index is cached
+func (n *N) cacheFile() string {
+	if n.channel != "" {
+		return filepath.Join(n.rootDir, "node_versions_"+n.channel+".json")
+	}
+
+	return filepath.Join(n.rootDir, "node_versions.json")
+}
+
+// releaseVersion parses the version of a release to compare it with a spec.
+// Everything in a channel is a prerelease, which constraints never match,
+// so unless the spec asks for a specific build, only the version it is a
+// build of is compared.
+func (n *N) releaseVersion(release nCacheItem) (*semverv3.Version, error) {
+	v, err := semverv3.NewVersion(release.Version)
+	if err != nil || n.channel == "" {
+		return v, err
+	}
+
+	if spec, ok := n.version.(*semverv3.Version); ok && spec.Prerelease() != "" {
+		return v, nil
+	}
+
+	stripped, err := v.SetPrerelease("")
+	if err != nil {
+		return nil, err
+	}
+
+	return &stripped, nil
+}
@@ -0,0 +1,106 @@
+package n
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+func TestChannels(t *testing.T) {
+	files := []string{"linux-" + runtime.GOARCH, "linux-x64", "linux-arm64", "osx-arm64-tar", "osx-x64-tar"}
+
+	rc, err := json.Marshal(nCache{
+		{Version: "v23.0.0-rc.1", Files: files},
+		{Version: "v22.1.0-rc.1", Files: files},
+		{Version: "v22.0.0-rc.3", Files: files},
+		{Version: "v22.0.0-rc.2", Files: files},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/download/rc/index.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(rc)
+	})
+
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	root := t.TempDir()
+	mirror := WithMirror(s.URL + "/download/release")
+
+	cases := map[string]string{
+		"rc":              "v23.0.0-rc.1",
+		"rc/22":           "v22.1.0-rc.1",
+		"rc/22.0":         "v22.0.0-rc.3",
+		"RC/22.0.0":       "v22.0.0-rc.3",
+		"rc/v22.0.0-rc.2": "v22.0.0-rc.2",
+		"rc/~22.0":        "v22.0.0-rc.3",
+	}
+
+	for spec, expected := range cases {
+		n, err := NewNodeManager(false, spec, root, mirror)
+		if err != nil {
+			t.Fatalf("%s: %v", spec, err)
+		}
+
+		if got := n.Resolution().Version; got != expected || n.Channel() != "rc" {
+			t.Fatalf("%s: expected %s from rc, got %s from %q", spec, expected, got, n.Channel())
+		}
+
+		if dir := filepath.Join(root, "channels", "rc", expected); filepath.Dir(filepath.Dir(n.InstallDir())) != dir {
+			t.Fatalf("%s: expected to be installed under %s, got %s", spec, dir, n.InstallDir())
+		}
+
+		// nested calls resolve to the same build
+		if !slices.Contains(n.environment, "NODE_VERSION=rc/"+expected) {
+			t.Fatalf("%s: expected NODE_VERSION to pin rc/%s", spec, expected)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "node_versions_rc.json")); err != nil {
+		t.Fatalf("expected the rc index to be cached separately: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "node_versions.json")); !os.IsNotExist(err) {
+		t.Fatalf("expected the release index to be left alone, got %v", err)
+	}
+
+	// through an alias, the release index says it's not an lts line
+	writeCache(t, root)
+
+	if err := SetAlias(root, "canary", "rc/22"); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := NewNodeManager(false, "canary", root, mirror); err != nil || n.Resolution().Version != "v22.1.0-rc.1" || n.Channel() != "rc" {
+		t.Fatalf("expected canary to be v22.1.0-rc.1 from rc, got %v", err)
+	}
+
+	if _, err := NewNodeManager(false, "rc/24", root, mirror); err == nil {
+		t.Fatal("expected rc/24 not to resolve")
+	}
+}
+
+func TestChannelMirrors(t *testing.T) {
+	got, err := channelMirrors([]string{"https://nodejs.org/download/release", "https://npmmirror.com/mirrors/node", "https://example.com/node/release/"}, "nightly")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"https://nodejs.org/download/nightly", "https://example.com/node/nightly"}
+	if !slices.Equal(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+
+	// not nodejs.org behind the mirror's back
+	if got, err := channelMirrors([]string{"https://npmmirror.com/mirrors/node"}, "rc"); err == nil {
+		t.Fatalf("expected an error for a mirror without channels, got %v", got)
+	}
+}
@@ -0,0 +1,162 @@
+package n
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	semverv3 "github.com/Masterminds/semver/v3"
+)
+
+// ltsName returns the lowercased codename of the LTS line release belongs to,
+// the index carries `false` for non LTS releases
+func (release *nCacheItem) ltsName() string {
+	name, ok := release.Lts.(string)
+	if !ok {
+		return ""
+	}
+
+	return strings.ToLower(name)
+}
+
+// ltsLines returns the codenames of every LTS line in the index, newest first
+func (c nCache) ltsLines() []string {
+	var (
+		lines []string
+		seen  = map[string]bool{}
+	)
+
+	for i := range c {
+		name := c[i].ltsName()
+		if name == "" || seen[name] {
+			continue
+		}
+
+		seen[name] = true
+		lines = append(lines, name)
+	}
+
+	return lines
+}
+
+// ltsCodename resolves the part after "lts/" in nvm style aliases, i.e. "*"
+// for the newest line, "-N" for N lines before it, or a codename like "iron".
+func (c nCache) ltsCodename(alias string) (string, error) {
+	lines := c.ltsLines()
+	if len(lines) == 0 {
+		return "", fmt.Errorf("no lts releases found in the release index")
+	}
+
+	if alias == "*" {
+		return lines[0], nil
+	}
+
+	if strings.HasPrefix(alias, "-") {
+		offset, err := strconv.Atoi(alias[1:])
+		if err != nil || offset < 0 {
+			return "", fmt.Errorf("invalid lts offset %q", alias)
+		}
+
+		if offset >= len(lines) {
+			return "", fmt.Errorf("lts/-%d is out of range, only %d lts lines are known", offset, len(lines))
+		}
+
+		return lines[offset], nil
+	}
+
+	alias = strings.ToLower(alias)
+
+	for _, line := range lines {
+		if line == alias {
+			return line, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown lts codename %q", alias)
+}
+
+// ltsSpec picks releases from a single lts line, optionally narrowed down by
+// a constraint
+type ltsSpec struct {
+	codename   string
+	constraint *nodeRange
+}
+
+func (s *ltsSpec) match(release *nCacheItem) bool {
+	if release.ltsName() != s.codename {
+		return false
+	}
+
+	if s.constraint == nil {
+		return true
+	}
+
+	v, err := semverv3.NewVersion(release.Version)
+	if err != nil {
+		return false
+	}
+
+	return s.constraint.Contains(v)
+}
+
+func (s *ltsSpec) String() string {
+	if s.constraint == nil {
+		return "lts " + s.codename
+	}
+
+	return "lts " + s.codename + ", " + s.constraint.String()
+}
+
+// parseLtsSpec parses specs that select an lts line:
+//
+//	lts, lts/*          the newest lts line
+//	lts-1, lts/-1       the line before the newest one, and so on
+//	iron, lts/iron      a line by its codename
+//
+// any of these can be followed by @<constraint> to stay within part of the
+// line, e.g. iron@~20.11 for the newest 20.11 patch.
+// ok is false if spec doesn't look like an lts spec at all.
+func (c nCache) parseLtsSpec(spec string) (s *ltsSpec, ok bool, err error) {
+	selector, constraint, narrowed := strings.Cut(strings.ToLower(strings.TrimSpace(spec)), "@")
+
+	var line string
+
+	switch {
+	case selector == "lts":
+		line = "*"
+	case strings.HasPrefix(selector, "lts/"):
+		line = strings.TrimPrefix(selector, "lts/")
+	case strings.HasPrefix(selector, "lts-"):
+		line = "-" + strings.TrimPrefix(selector, "lts-")
+	default:
+		if !c.hasLtsLine(selector) {
+			return nil, false, nil
+		}
+
+		line = selector
+	}
+
+	s = &ltsSpec{}
+
+	if s.codename, err = c.ltsCodename(line); err != nil {
+		return nil, true, err
+	}
+
+	if narrowed {
+		if s.constraint, err = parseRange(constraint, true); err != nil {
+			return nil, true, fmt.Errorf("invalid constraint in %q: %w", spec, err)
+		}
+	}
+
+	return s, true, nil
+}
+
+func (c nCache) hasLtsLine(codename string) bool {
+	for _, line := range c.ltsLines() {
+		if line == codename {
+			return true
+		}
+	}
+
+	return false
+}
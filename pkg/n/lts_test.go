@@ -0,0 +1,83 @@
+package n
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func testCache() nCache {
+	files := []string{"linux-" + runtime.GOARCH, "linux-x64", "osx-arm64-tar", "osx-x64-tar"}
+
+	return nCache{
+		{Version: "v23.1.0", Files: files, Lts: false},
+		{Version: "v22.11.0", Files: files, Lts: "Jod"},
+		{Version: "v22.10.0", Files: files, Lts: false},
+		{Version: "v20.18.0", Date: "2024-10-03", Files: files, Npm: "10.8.2", V8: "11.3.244.8-node.23", Lts: "Iron"},
+		{Version: "v20.11.1", Files: files, Lts: "Iron", Security: true},
+		{Version: "v20.11.0", Files: files, Lts: "Iron"},
+		{Version: "v18.20.4", Files: files, Lts: "Hydrogen"},
+		{Version: "v18.17.0", Files: files, Lts: "Hydrogen"},
+	}
+}
+
+// writeCache makes testCache the fresh release index cache of root
+func writeCache(t *testing.T, root string) {
+	t.Helper()
+
+	b, err := json.Marshal(testCache())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "node_versions.json"), b, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLtsSpec(t *testing.T) {
+	cases := map[string]string{
+		"lts":            "v22.11.0",
+		"lts/*":          "v22.11.0",
+		"lts-1":          "v20.18.0",
+		"lts/-1":         "v20.18.0",
+		"lts-2":          "v18.20.4",
+		"iron":           "v20.18.0",
+		"Iron":           "v20.18.0",
+		"lts/hydrogen":   "v18.20.4",
+		"iron@~20.11":    "v20.11.1",
+		"lts-1@<20.11.1": "v20.11.0",
+	}
+
+	for spec, expected := range cases {
+		n := &N{cache: testCache(), arch: "x64"}
+
+		lts, ok, err := n.cache.parseLtsSpec(spec)
+		if err != nil || !ok {
+			t.Fatalf("%s: expected an lts spec, got ok=%v err=%v", spec, ok, err)
+		}
+
+		got, err := n.findLatestVersion(lts.match)
+		if err != nil {
+			t.Fatalf("%s: %v", spec, err)
+		}
+
+		if got != expected {
+			t.Fatalf("%s: expected %s, got %s", spec, expected, got)
+		}
+	}
+
+	for _, spec := range []string{"20", "^18", "latest", "gallium"} {
+		if _, ok, _ := testCache().parseLtsSpec(spec); ok {
+			t.Fatalf("%s should not be taken as an lts spec", spec)
+		}
+	}
+
+	for _, spec := range []string{"lts-3", "lts/gallium", "lts-x", "iron@nope"} {
+		if _, _, err := testCache().parseLtsSpec(spec); err == nil {
+			t.Fatalf("%s should fail to resolve", spec)
+		}
+	}
+}
@@ -4,9 +4,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"io/fs"
-	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -16,7 +13,7 @@ import (
 
 	semverv3 "github.com/Masterminds/semver/v3"
 	gopark "github.com/debdutdeb/gopark/pkg/utils"
-	"github.com/debdutdeb/novm/v3/utils"
+	"github.com/debdutdeb/novm/v3/utils/tty"
 )
 
 var ErrNodeNotInstalled = errors.New("nodejs not installed")
@@ -28,6 +25,8 @@ type nCacheItem struct {
 	Version  string      `json:"version"`
 	Date     string      `json:"date"`
 	Files    []string    `json:"files"`
+	Npm      string      `json:"npm,omitempty"`
+	V8       string      `json:"v8,omitempty"`
 	Lts      interface{} `json:"lts,omitempty"`
 	Security bool        `json:"security"`
 }
@@ -47,6 +46,40 @@ type N struct {
 	cache nCache
 
 	version SemverManager
+
+	resolution Resolution
+
+	// mirrors are tried in order, see WithMirrors
+	mirrors []string
+
+	// offline resolves against the installed versions only
+	offline bool
+
+	// refreshErr is why the cached release index in use couldn't be
+	// refreshed
+	refreshErr error
+
+	// maxAge is how old the release index can get before it is refreshed,
+	// with background a stale index is used while it is, see stale
+	maxAge     time.Duration
+	background bool
+	stale      bool
+
+	strategy Strategy
+
+	securityUpgrade bool
+
+	// schedule is where the release schedule is downloaded from
+	schedule string
+
+	// channel is the prerelease channel the version is from, empty for
+	// releases
+	channel string
+
+	// unofficialMirrors are where builds missing from the official index
+	// are looked for, see WithUnofficialBuilds
+	unofficialMirrors []string
+	unofficialCache   nCache
 }
 
 type nodeScriptWrapper interface {
@@ -76,37 +109,78 @@ type Npx nodeScriptWrapper
 type Corepack nodeScriptWrapper
 type Pnpm nodeScriptWrapper
 
-func NewNodeManager(global bool, version string, rootDir string) (*N, error) {
-	n := &N{
-		global:     global,
-		rootDir:    rootDir,
-		versionStr: version,
+func NewNodeManager(global bool, version string, rootDir string, opts ...Option) (*N, error) {
+	n, err := NewResolver(rootDir, opts...).resolve(version)
+	if err != nil {
+		return nil, err
 	}
 
-	var err error
+	n.global = global
 
-	if err := n.initCache(); err != nil {
-		return nil, err
+	n.installDir = filepath.Join(n.versionsDir(), n.versionStr, runtime.GOOS, n.arch)
+
+	pinned := n.versionStr
+	if n.channel != "" {
+		pinned = n.channel + "/" + n.versionStr
 	}
 
-	switch version {
-	case "latest":
-		n.arch = n.getNodeJsArch()
+	n.environment = append(os.Environ(), "NODE_VERSION="+pinned) // make sure we continue using this version on every nested call (like lifecycle scripts) in case source isn't environment variable
 
-		if n.versionStr, err = n.findLatestVersion(false); err != nil {
-			return nil, err
+	if n.global {
+		binPath, err := exec.LookPath("node")
+		if err != nil && errors.Is(err, exec.ErrNotFound) {
+			return nil, ErrNodeNotInstalled
+		} else if err != nil {
+			return nil, fmt.Errorf("unknown error trying to detect nodejs global installation: %v", err)
 		}
-	case "lts":
+
+		n.binPath = binPath
+
+		return n, nil
+	}
+
+	n.binPath = filepath.Join(n.installDir, "bin", "node")
+
+	path := os.Getenv("PATH")
+
+	n.environment = append([]string{fmt.Sprintf("PATH=%s:%s", filepath.Dir(n.binPath), path)}, n.environment...)
+
+	return n, nil
+}
+
+// resolve picks the release version stands for out of the cache
+func (n *N) resolve(version string) (err error) {
+	switch {
+	case version == "latest" || version == "node" || version == "stable":
 		n.arch = n.getNodeJsArch()
 
-		if n.versionStr, err = n.findLatestVersion(true); err != nil {
-			return nil, err
+		if n.versionStr, err = n.findLatestVersion(func(*nCacheItem) bool { return true }); err != nil {
+			return err
 		}
 	default:
+		lts, ok, err := n.cache.parseLtsSpec(version)
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			n.resolution.Constraint = lts.String()
+
+			n.arch = n.getNodeJsArch()
+
+			if n.versionStr, err = n.findLatestVersion(lts.match); err != nil {
+				return err
+			}
+
+			break
+		}
+
 		if n.version, err = n.parseVersion(version); err != nil {
-			return nil, err
+			return err
 		}
 
+		n.resolution.Constraint = fmt.Sprint(n.version)
+
 		n.arch = n.getNodeJsArch()
 
 		found := false
@@ -114,119 +188,118 @@ func NewNodeManager(global bool, version string, rootDir string) (*N, error) {
 		var releases []nCacheItem
 
 		for _, release := range n.cache {
-			c := n.version.Compare(semverv3.MustParse(release.Version))
-			// if c == 3 {
-			// 	break
-			// }
-
-			if c == 0 {
-				releases = append(releases, release)
+			v, err := n.releaseVersion(release)
+			if err != nil {
+				continue
+			}
 
-				break
+			ok, exact := match(n.version, v)
+			if !ok {
+				continue
 			}
 
-			if c == 2 {
-				releases = append(releases, release)
+			releases = append(releases, release)
 
-				continue
+			if exact {
+				break
 			}
 		}
 
 		if len(releases) == 0 {
-			return nil, fmt.Errorf("no release found for version: \"%s\"", version)
+			return &NoMatchError{Spec: version}
 		}
 
 		archiveType := n.getArchiveType()
 
-	loop:
-		for _, release := range releases {
-			for _, thisType := range release.Files {
-				if thisType == archiveType {
-					n.versionStr = release.Version
-
-					found = true
+		n.resolution.ArchiveType = archiveType
+		n.resolution.Strategy = n.strategy
 
-					break loop
-				}
-			}
+		if releases, err = n.order(releases); err != nil {
+			return err
 		}
 
-		if !found {
-			return nil, fmt.Errorf("version %s not found for file %s, err: %w", version, archiveType, ErrNodeVersionNotFound)
-		}
-	}
+		for _, release := range releases {
+			if found, n.resolution.Unofficial = n.build(release, archiveType); found {
+				n.versionStr = release.Version
 
-	n.installDir = filepath.Join(rootDir, "versions", n.versionStr, runtime.GOOS, n.arch)
-	n.environment = append(os.Environ(), "NODE_VERSION="+n.versionStr) // make sure we continue using this version on every nested call (like lifecycle scripts) in case source isn't environment variable
+				break
+			}
 
-	if n.global {
-		binPath, err := exec.LookPath("node")
-		if err != nil && errors.Is(err, exec.ErrNotFound) {
-			return nil, ErrNodeNotInstalled
-		} else if err != nil {
-			return nil, fmt.Errorf("unknown error trying to detect nodejs global installation: %v", err)
+			n.resolution.skip(release.Version, "no "+archiveType+" build")
 		}
 
-		n.binPath = binPath
-
-		return n, nil
+		if !found {
+			return &NoMatchError{Spec: version, ArchiveType: archiveType, Rejected: n.resolution.Skipped}
+		}
 	}
 
-	n.binPath = filepath.Join(n.installDir, "bin", "node")
-
-	path := os.Getenv("PATH")
-
-	n.environment = append([]string{fmt.Sprintf("PATH=%s:%s", filepath.Dir(n.binPath), path)}, n.environment...)
-
-	return n, nil
+	return nil
 }
 
+// parseVersion parses a full version, e.g. 20.11.0 or v20.11.0, as exactly
+// that version and anything else as a range. Partial versions like 20 or
+// 20.11 are ranges, 20.x and 20.11.x, as they are to npm.
 func (n *N) parseVersion(version string) (SemverManager, error) {
-	var semverManager SemverManager
-
-	semverManager, err1 := semverv3.NewVersion(version)
-	if err1 == nil {
-		return semverManager, nil
+	if v, err := semverv3.StrictNewVersion(strings.TrimLeft(strings.TrimSpace(version), "=v")); err == nil {
+		return v, nil
 	}
 
-	c, err2 := semverv3.NewConstraint(version)
-	if err2 == nil {
-		return semverv3Constraints(*c), nil
+	// ranges mean what they mean to npm, whose engine-strict lets
+	// prereleases through
+	r, err := parseRange(version, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse version, neither a semver nor constraint: %w", err)
 	}
 
-	return nil, fmt.Errorf("failed to parse version, neither a semver nor constraint: %w, %w", err1, err2)
+	return r, nil
 }
 
+// nodeArches maps GOARCH to the arch node releases are named with
+var nodeArches = map[string]string{
+	"amd64":   "x64",
+	"arm64":   "arm64",
+	"arm":     "armv7l",
+	"386":     "x86",
+	"ppc64le": "ppc64le",
+	"ppc64":   "ppc64",
+	"s390x":   "s390x",
+	"riscv64": "riscv64",
+	"loong64": "loong64",
+}
+
+// getNodeJsArch returns the arch of the build to install, with a -musl suffix
+// on musl systems, the way unofficial builds name them
 func (n *N) getNodeJsArch() string {
-	if runtime.GOARCH == "amd64" {
-		return "x64"
+	arch, ok := nodeArches[runtime.GOARCH]
+	if !ok {
+		arch = runtime.GOARCH
 	}
 
-	if n.versionStr == "latest" || n.versionStr == "lts" {
-		return runtime.GOARCH
+	if runtime.GOOS == "linux" && isMusl() {
+		return arch + "-musl"
 	}
 
-	// TODO: try to remove these type assertions
-	if runtime.GOOS == "darwin" {
-		c, _ := semverv3.NewConstraint("<16.0.0")
-
-		// if source has a constraint set, unfortunately for now
-		// get the actual typed variable out and use that
-		constraint, ok := n.version.(semverv3Constraints)
-		if ok {
-			if semverv3.Constraints(constraint).CheckConstraints(c) {
-				return "x64"
-			}
+	// latest and lts aliases aren't parsed, the newest releases all have arm builds
+	if runtime.GOOS != "darwin" || n.version == nil {
+		return arch
+	}
 
-			return runtime.GOARCH
-		}
+	v16 := semverv3.New(16, 0, 0, "", "")
 
-		if c.Check(n.version.(*semverv3.Version)) {
+	// TODO: try to remove these type assertions
+	if r, ok := n.version.(*nodeRange); ok {
+		if r.below(v16) {
 			return "x64"
 		}
+
+		return arch
 	}
 
-	return runtime.GOARCH
+	if n.version.(*semverv3.Version).LessThan(v16) {
+		return "x64"
+	}
+
+	return arch
 }
 
 // SetBinaryArchX86 is used for apple m-series/arm series machines
@@ -236,43 +309,43 @@ func (n *N) SetBinaryArchX86() {
 }
 
 func (n *N) getArchiveType() string {
-	finalArch := n.arch
+	return archiveType(n.arch)
+}
 
+// archiveType is the name the release index gives this platform's build for arch
+func archiveType(arch string) string {
 	if runtime.GOOS == "linux" {
-		return "linux-" + finalArch
+		return "linux-" + arch
 	}
 
 	if runtime.GOOS == "darwin" {
-		return "osx-" + finalArch + "-tar"
+		return "osx-" + arch + "-tar"
 	}
 
 	return runtime.GOOS + "-" + runtime.GOARCH
 }
 
-func (n *N) findLatestVersion(lts bool) (string, error) {
+// findLatestVersion returns the newest release matching filter that has a build for this platform
+func (n *N) findLatestVersion(filter func(release *nCacheItem) bool) (string, error) {
 	fileType := n.getArchiveType()
 
-	isLts := func(release *nCacheItem) bool {
-		if _, ok := release.Lts.(string); !ok {
-			return false
-		}
-
-		return true
-	}
+	n.resolution.ArchiveType = fileType
 
 	for _, release := range n.cache {
-		if lts && !isLts(&release) {
+		if !filter(&release) {
 			continue
 		}
 
-		for _, file := range release.Files {
-			if file == fileType {
-				return release.Version, nil
-			}
+		if found, unofficial := n.build(release, fileType); found {
+			n.resolution.Unofficial = unofficial
+
+			return release.Version, nil
 		}
+
+		n.resolution.skip(release.Version, "no "+fileType+" build")
 	}
 
-	return "", fmt.Errorf("failed to find latest version for file %s", fileType)
+	return "", &NoMatchError{Spec: n.resolution.Spec, ArchiveType: fileType, Rejected: n.resolution.Skipped}
 }
 
 func (n *N) Npm() Npm {
@@ -311,31 +384,30 @@ func (n *N) Install() error {
 		return fmt.Errorf("failed to create temporary directory to install nodejs: %v", err)
 	}
 
-	url, filename := n._assets()
+	urls, filename := n._assets()
 
 	archivePath := filepath.Join(tmpDir, filename)
 
-	if utils.IsInteractive() {
-		err = gopark.DownloadWithProgressBar("Node "+n.versionStr, url, archivePath)
-		if err != nil {
-			return err
-		}
-	} else {
-		err = gopark.DownloadSilent(url, archivePath)
-		if err != nil {
-			return err
+	var errs []error
+
+	for _, url := range urls {
+		if err = n.download(url, archivePath); err == nil {
+			break
 		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", url, err))
+
+		// don't leave a partial download for the next mirror to append to
+		os.Remove(archivePath)
 	}
 
-	cmd := exec.Command("xz", "--decompress", archivePath)
-	err = cmd.Run()
-	if err != nil {
-		return err
+	if len(errs) == len(urls) {
+		return fmt.Errorf("failed to download nodejs %s: %w", n.versionStr, errors.Join(errs...))
 	}
 
 	archivePath = strings.TrimSuffix(archivePath, ".xz")
 
-	cmd = exec.Command("tar", "xf", archivePath)
+	cmd := exec.Command("tar", "xf", archivePath)
 	cmd.Dir = tmpDir
 	if err = cmd.Run(); err != nil {
 		return err
@@ -360,8 +432,18 @@ func (n *N) Install() error {
 	return nil
 }
 
+// InstallDir is where the resolved version is installed, or would be
+func (n *N) InstallDir() string {
+	return n.installDir
+}
+
+// Installed reports whether the resolved version is installed
+func (n *N) Installed() bool {
+	return n.versionStr == n.Version()
+}
+
 func (n *N) EnsureInstalled() error {
-	if n.versionStr == n.Version() {
+	if n.Installed() {
 		return nil
 	}
 
@@ -411,9 +493,41 @@ func (n *N) Version() string {
 	return string(out[:len(out)-1])
 }
 
-func (n *N) _assets() (url string, filename string) {
+// download downloads and decompresses the archive at url to path. Only 404s
+// fail the download itself, an error page from a broken mirror fails to
+// decompress instead.
+func (n *N) download(url, path string) error {
+	var err error
+
+	if tty.IsInteractive() {
+		err = gopark.DownloadWithProgressBar("Node "+n.versionStr, url, path)
+	} else {
+		err = gopark.DownloadSilent(url, path)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if out, err := exec.Command("xz", "--decompress", path).CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+// _assets returns the urls of the archive to install, one per mirror
+func (n *N) _assets() (urls []string, filename string) {
 	filename = fmt.Sprintf("node-%s-%s-%s.tar.xz", n.versionStr, runtime.GOOS, n.arch)
-	url = fmt.Sprintf("https://nodejs.org/download/release/%s/%s", n.versionStr, filename)
+
+	mirrors := n.mirrors
+	if n.resolution.Unofficial {
+		mirrors = n.unofficialMirrors
+	}
+
+	for _, mirror := range mirrors {
+		urls = append(urls, fmt.Sprintf("%s/%s/%s", mirror, n.versionStr, filename))
+	}
 	return
 }
 
@@ -433,65 +547,78 @@ func (n *N) initCache() error {
 		}
 	}
 
-	cacheFilename := filepath.Join(n.rootDir, "node_versions.json")
+	path := n.cacheFile()
 
-	var (
-		cacheExists bool = true
-		stat        fs.FileInfo
-		err         error
-	)
+	age, ok := usable(path)
 
-	if stat, err = os.Stat(cacheFilename); err != nil {
-		if os.IsNotExist(err) {
-			cacheExists = false
-		} else {
-			return err
+	switch {
+	case n.offline:
+	case n.background && ok && age >= n.maxAge:
+		// used as is, the caller refreshes it, see WithBackgroundRefresh
+		n.stale = true
+	default:
+		if err := n.refreshIndex(); err != nil && ok {
+			// an old index is still better than the installed versions
+			n.refreshErr = err
+		} else if err != nil {
+			// without the index only what is installed can be used
+			n.offline = true
 		}
 	}
 
-	cacheFile, err := os.OpenFile(cacheFilename, os.O_CREATE|os.O_RDWR, 0750)
-	if err != nil {
-		return err
+	err := n.loadIndex(path)
+	if err != nil && !n.offline {
+		n.offline = true
+		return n.loadCache(path)
 	}
 
-	var data nCache
-
-	if cacheExists && time.Since(stat.ModTime()) < (time.Hour*24) {
-		if err = json.NewDecoder(cacheFile).Decode(&data); err != nil {
-			return err
-		}
+	return err
+}
 
-		n.cache = data
-		return nil
+// loadIndex loads the release index at path. Downloads are checked before
+// they're written, a copy that doesn't parse, e.g. written by an older novm,
+// is removed and downloaded again.
+func (n *N) loadIndex(path string) error {
+	err := n.loadCache(path)
+	if err == nil || n.offline || os.IsNotExist(err) {
+		return err
 	}
 
-	resp, err := http.Get("https://nodejs.org/download/release/index.json")
-	if err != nil {
-		if cacheExists {
-			if err := json.NewDecoder(cacheFile).Decode(&data); err != nil {
-				return err
-			}
-
-			n.cache = data
-			return nil
-		}
+	os.Remove(path)
 
+	if err := n.refreshIndex(); err != nil {
 		return err
 	}
 
-	content, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return err
-	}
+	n.stale = false
 
-	resp.Body.Close()
+	return n.loadCache(path)
+}
 
-	_, err = cacheFile.Write(content)
-	if err != nil {
-		return err
+// refreshIndex refreshes the release index n uses if it is older than the
+// max age
+func (n *N) refreshIndex() error {
+	return refresh(n.cacheFile(), n.maxAge, func(prev validators) ([]byte, validators, error) {
+		return fetchIndex(n.mirrors, prev)
+	})
+}
+
+// loadCache reads the release index at path, only keeping installed versions
+// offline
+func (n *N) loadCache(path string) error {
+	var data nCache
+
+	content, err := os.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(content, &data)
 	}
 
-	if err = json.Unmarshal(content, &data); err != nil {
+	if n.offline {
+		// a stale copy still knows which of the installed versions are lts
+		return n.installedCache(data)
+	}
+
+	if err != nil {
 		return err
 	}
 
@@ -500,6 +627,19 @@ func (n *N) initCache() error {
 	return nil
 }
 
+// Setenv sets key in the environment node and everything it runs get,
+// replacing the value inherited from novm's own environment if any
+func (n *N) Setenv(key, value string) {
+	environment := make([]string, 0, len(n.environment)+1)
+	for _, kv := range n.environment {
+		if !strings.HasPrefix(kv, key+"=") {
+			environment = append(environment, kv)
+		}
+	}
+
+	n.environment = append(environment, key+"="+value)
+}
+
 func (n *N) Experimental_UnderlyingStdCmd(args ...string) *exec.Cmd {
 	cmd := exec.Command(n.binPath, args...)
 
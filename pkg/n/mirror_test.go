@@ -0,0 +1,99 @@
+package n
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// archive makes the tar.xz of a fake nodejs release at path
+func archive(t *testing.T, path, version, arch string) {
+	t.Helper()
+
+	if _, err := exec.LookPath("xz"); err != nil {
+		t.Skip("xz is needed to build an archive")
+	}
+
+	dir := t.TempDir()
+	name := "node-" + version + "-" + runtime.GOOS + "-" + arch
+
+	for _, sub := range []string{"share", "lib", "include", "bin"} {
+		if err := os.MkdirAll(filepath.Join(dir, name, sub), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, name, "bin", "node"), []byte("#!/bin/sh\necho "+version+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if out, err := exec.Command("tar", "-cJf", path, "-C", dir, name).CombinedOutput(); err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+}
+
+func TestMirrorFailover(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "maintenance", http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+
+	portal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html>sign in</html>"))
+	}))
+	defer portal.Close()
+
+	index, err := json.Marshal(testCache())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tarball := filepath.Join(t.TempDir(), "node.tar.xz")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/index.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(index)
+	})
+	mux.HandleFunc("/v20.18.0/", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, tarball)
+	})
+
+	mirror := httptest.NewServer(mux)
+	defer mirror.Close()
+
+	root := t.TempDir()
+
+	n, err := NewNodeManager(false, "^20", root, WithMirrors(down.URL, portal.URL+"/", "", mirror.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n.Offline() || n.Resolution().Version != "v20.18.0" {
+		t.Fatalf("expected v20.18.0 from the third mirror, got %s (offline=%v)", n.Resolution().Version, n.Offline())
+	}
+
+	if got := n.Mirrors(); len(got) != 3 || got[1] != portal.URL {
+		t.Fatalf("unexpected mirrors %v", got)
+	}
+
+	archive(t, tarball, "v20.18.0", n.arch)
+
+	if err := n.Install(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(n.binPath); err != nil {
+		t.Fatalf("expected node to be installed from the third mirror: %v", err)
+	}
+
+	// nothing is installed to fall back to
+	if _, err := NewNodeManager(false, "^20", t.TempDir(), WithMirrors(down.URL, portal.URL)); !errors.Is(err, ErrOffline) {
+		t.Fatalf("expected ErrOffline when no mirror serves the index, got %v", err)
+	}
+}
@@ -4,26 +4,17 @@ import (
 	semverv3 "github.com/Masterminds/semver/v3"
 )
 
-type SemverManager interface {
-	Compare(v *semverv3.Version) int
-}
-
-type semverv3Constraints semverv3.Constraints
-
-// Since this works with constraints, we only know if the version satisfies the constraint or not
-// if satisfies, we return 2 to complement semver.Compare's returns
+// SemverManager is what a version spec is parsed to, an exact
+// *semverv3.Version or a *nodeRange. Compare returns
 //
 //	-1 - a is less than b
 //	 0 - a is equal to b
 //	 1 - a is breater than b
 //	 2 - a satisfies b
 //	 3 - a does not satisfy b
-func (c semverv3Constraints) Compare(v *semverv3.Version) int {
-	_c := semverv3.Constraints(c)
-
-	if _c.Check(v) {
-		return 2
-	}
-
-	return 3
+//
+// ranges only know if a version satisfies them or not, so they return 2 or 3
+// to complement semver.Compare's returns
+type SemverManager interface {
+	Compare(v *semverv3.Version) int
 }
@@ -0,0 +1,84 @@
+package n
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+
+	semverv3 "github.com/Masterminds/semver/v3"
+)
+
+// ErrOffline is returned, wrapped, when a version can't be resolved because
+// only installed versions could be considered
+var ErrOffline = errors.New("offline, only installed versions can be used")
+
+// WithOffline resolves versions against the ones installed under rootDir
+// without fetching the release index. NewNodeManager also falls back to this
+// on its own when the index can't be fetched and there is no cached copy.
+func WithOffline(offline bool) Option {
+	return func(n *N) {
+		n.offline = offline
+	}
+}
+
+// Offline reports whether n was resolved against installed versions only
+func (n *N) Offline() bool {
+	return n.offline
+}
+
+// RefreshError is why the release index n was resolved against couldn't be
+// refreshed, nil if it didn't need to be or was. An older cached copy was
+// used instead.
+func (n *N) RefreshError() error {
+	return n.refreshErr
+}
+
+// installedCache makes the cache the versions installed under rootDir, newest
+// first, each with a build for every arch it is installed for. Releases in
+// index lend them what the directory layout can't tell, e.g. lts.
+func (n *N) installedCache(index nCache) error {
+	known := make(map[string]nCacheItem, len(index))
+	for _, release := range index {
+		known[release.Version] = release
+	}
+
+	dirs, err := os.ReadDir(n.versionsDir())
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	var cache nCache
+
+	for _, dir := range dirs {
+		if _, err := semverv3.NewVersion(dir.Name()); err != nil || !dir.IsDir() {
+			continue
+		}
+
+		arches, err := os.ReadDir(filepath.Join(n.versionsDir(), dir.Name(), runtime.GOOS))
+		if err != nil {
+			continue
+		}
+
+		release, ok := known[dir.Name()]
+		if !ok {
+			release = nCacheItem{Version: dir.Name(), Lts: false}
+		}
+
+		release.Files = nil
+		for _, arch := range arches {
+			release.Files = append(release.Files, archiveType(arch.Name()))
+		}
+
+		cache = append(cache, release)
+	}
+
+	sort.Slice(cache, func(i, j int) bool {
+		return semverv3.MustParse(cache[i].Version).GreaterThan(semverv3.MustParse(cache[j].Version))
+	})
+
+	n.cache = cache
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package n
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+var oldTime = time.Now().Add(-48 * time.Hour)
+
+func installFake(t *testing.T, root string, versions ...string) {
+	t.Helper()
+
+	arch := runtime.GOARCH
+	if arch == "amd64" {
+		arch = "x64"
+	}
+
+	for _, v := range versions {
+		bin := filepath.Join(root, "versions", v, runtime.GOOS, arch, "bin")
+
+		if err := os.MkdirAll(bin, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(filepath.Join(bin, "node"), nil, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestOffline(t *testing.T) {
+	root := t.TempDir()
+	installFake(t, root, "v20.11.0", "v18.20.4")
+
+	// not a version, ignored
+	if err := os.MkdirAll(filepath.Join(root, "versions", "tmp"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]string{
+		"latest":  "v20.11.0",
+		"18.x":    "v18.20.4",
+		"^20":     "v20.11.0",
+		"18.20.4": "v18.20.4",
+	}
+
+	for spec, expected := range cases {
+		n, err := NewNodeManager(false, spec, root, WithOffline(true))
+		if err != nil {
+			t.Fatalf("%s: %v", spec, err)
+		}
+
+		if got := n.Resolution().Version; got != expected || !n.Offline() {
+			t.Fatalf("%s: expected %s offline, got %s (offline=%v)", spec, expected, got, n.Offline())
+		}
+	}
+
+	for _, spec := range []string{"22", "20.12.0", "lts"} {
+		if _, err := NewNodeManager(false, spec, root, WithOffline(true)); !errors.Is(err, ErrOffline) {
+			t.Fatalf("%s: expected ErrOffline, got %v", spec, err)
+		}
+	}
+}
+
+func TestOfflineOnNetworkFailure(t *testing.T) {
+	root := t.TempDir()
+	installFake(t, root, "v20.11.0", "v18.20.4")
+
+	unreachable := WithMirror("http://127.0.0.1:1")
+
+	n, err := NewNodeManager(false, "18.x", root, unreachable)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !n.Offline() || n.Resolution().Version != "v18.20.4" {
+		t.Fatalf("expected to fall back to the installed v18.20.4, got %s (offline=%v)", n.Resolution().Version, n.Offline())
+	}
+
+	// a stale index is still used
+	b, err := json.Marshal(testCache())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "node_versions.json"), b, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chtimes(filepath.Join(root, "node_versions.json"), oldTime, oldTime); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err = NewNodeManager(false, "lts", root, unreachable)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n.Offline() || n.RefreshError() == nil || n.Resolution().Version != "v22.11.0" {
+		t.Fatalf("expected v22.11.0 from the stale index, got %s (offline=%v)", n.Resolution().Version, n.Offline())
+	}
+
+	// and offline knows which installed versions are lts
+	for spec, expected := range map[string]string{"lts": "v20.11.0", "hydrogen": "v18.20.4"} {
+		n, err := NewNodeManager(false, spec, root, WithOffline(true))
+		if err != nil {
+			t.Fatalf("%s: %v", spec, err)
+		}
+
+		if got := n.Resolution().Version; got != expected {
+			t.Fatalf("%s: expected %s, got %s", spec, expected, got)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package n
+
+import "strings"
+
+// DefaultMirror is where releases are downloaded from unless WithMirror says
+// otherwise
+const DefaultMirror = "https://nodejs.org/download/release"
+
+// Option configures an N, see NewNodeManager
+type Option func(n *N)
+
+// WithMirror downloads the release index and releases from mirror, a base
+// URL laid out like DefaultMirror. An empty mirror keeps the default.
+func WithMirror(mirror string) Option {
+	return WithMirrors(mirror)
+}
+
+// WithMirrors is WithMirror with failover: each mirror is tried in turn until
+// one serves the release index, and the same for every download. Empty
+// mirrors are ignored, with none left the default is kept.
+func WithMirrors(mirrors ...string) Option {
+	return func(n *N) {
+		var list []string
+		for _, mirror := range mirrors {
+			if mirror != "" {
+				list = append(list, trimMirror(mirror))
+			}
+		}
+
+		if len(list) > 0 {
+			n.mirrors = list
+		}
+	}
+}
+
+// Mirrors returns the base URLs n downloads from, in the order they are tried
+func (n *N) Mirrors() []string {
+	return append([]string(nil), n.mirrors...)
+}
+
+func trimMirror(mirror string) string {
+	return strings.TrimSuffix(mirror, "/")
+}
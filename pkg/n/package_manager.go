@@ -0,0 +1,163 @@
+package n
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	semverv3 "github.com/Masterminds/semver/v3"
+)
+
+// YarnVersion is like Yarn but pinned to version, as asked for by the
+// packageManager field of a package.json. It is installed with npm under
+// rootDir/package-managers the first time it is asked for.
+func (n *N) YarnVersion(version string) (Yarn, error) {
+	pkg := "yarn"
+
+	// yarn 2 and up isn't published as yarn
+	if major, err := strconv.Atoi(strings.SplitN(strings.TrimLeft(version, "^~=v"), ".", 2)[0]); err == nil && major >= 2 {
+		pkg = "@yarnpkg/cli-dist"
+	}
+
+	return n.pinnedPackageManager(pkg, "yarn", version)
+}
+
+// PnpmVersion is like Pnpm but pinned to version, see YarnVersion
+func (n *N) PnpmVersion(version string) (Pnpm, error) {
+	return n.pinnedPackageManager("pnpm", "pnpm", version)
+}
+
+func (n *N) pinnedPackageManager(pkg, bin, version string) (nodeScriptWrapper, error) {
+	version, err := n.resolvePackageManager(pkg, bin, version)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := filepath.Join(n.packageManagersDir(), bin+"@"+version)
+
+	pm := *n
+	pm.binPath = filepath.Join(prefix, "bin", bin)
+
+	if _, err := os.Stat(pm.binPath); err == nil {
+		return &pm, nil
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if err := n.Npm().Run("install", "--global", "--prefix", prefix, pkg+"@"+version); err != nil {
+		return nil, fmt.Errorf("failed to install %s@%s: %w", bin, version, err)
+	}
+
+	return &pm, nil
+}
+
+func (n *N) packageManagersDir() string {
+	return filepath.Join(n.rootDir, "package-managers")
+}
+
+// resolvePackageManager returns the version of pkg that version stands for,
+// an exact version as is and a range the newest release of it in the
+// registry. What a range resolved to is kept for the max age, like the
+// release index, so not every run asks the registry. If the registry can't
+// be asked, the newest installed version satisfying the range is used.
+func (n *N) resolvePackageManager(pkg, bin, version string) (string, error) {
+	if v, err := semverv3.StrictNewVersion(strings.TrimLeft(strings.TrimSpace(version), "=v")); err == nil {
+		return v.String(), nil
+	}
+
+	r, err := parseRange(version, false)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s version %q: %w", bin, version, err)
+	}
+
+	path := filepath.Join(n.packageManagersDir(), bin+"@"+version+".resolved")
+
+	if fresh(path, n.maxAge) {
+		if content, err := os.ReadFile(path); err == nil {
+			return string(content), nil
+		}
+	}
+
+	newest, err := n.newestPublished(pkg, r)
+	if err == nil {
+		if err := os.MkdirAll(n.packageManagersDir(), 0750); err == nil {
+			writeFile(path, []byte(newest))
+		}
+
+		return newest, nil
+	}
+
+	if installed := n.newestInstalledPackageManager(bin, r); installed != "" {
+		return installed, nil
+	}
+
+	return "", fmt.Errorf("failed to resolve %s@%s: %w", bin, version, err)
+}
+
+// newestPublished asks the registry for the newest version of pkg satisfying
+// r
+func (n *N) newestPublished(pkg string, r *nodeRange) (string, error) {
+	stdout, stderr, err := n.Npm().CaptureOutput("view", pkg+"@"+r.String(), "version", "--json")
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(string(stderr)))
+	}
+
+	// a string when one version matches, a list when more do
+	var versions []string
+	if err := json.Unmarshal(stdout, &versions); err != nil {
+		var version string
+		if err := json.Unmarshal(stdout, &version); err != nil {
+			return "", fmt.Errorf("unexpected npm view output %q", stdout)
+		}
+
+		versions = []string{version}
+	}
+
+	return newestOf(versions, r)
+}
+
+// newestInstalledPackageManager returns the newest version of bin installed
+// under rootDir/package-managers that satisfies r, empty if there is none
+func (n *N) newestInstalledPackageManager(bin string, r *nodeRange) string {
+	entries, err := os.ReadDir(n.packageManagersDir())
+	if err != nil {
+		return ""
+	}
+
+	var versions []string
+
+	for _, entry := range entries {
+		if version, ok := strings.CutPrefix(entry.Name(), bin+"@"); ok && entry.IsDir() {
+			versions = append(versions, version)
+		}
+	}
+
+	newest, _ := newestOf(versions, r)
+
+	return newest
+}
+
+// newestOf returns the newest of versions satisfying r
+func newestOf(versions []string, r *nodeRange) (string, error) {
+	var newest *semverv3.Version
+
+	for _, version := range versions {
+		v, err := semverv3.StrictNewVersion(version)
+		if err != nil || !r.Contains(v) {
+			continue
+		}
+
+		if newest == nil || v.GreaterThan(newest) {
+			newest = v
+		}
+	}
+
+	if newest == nil {
+		return "", fmt.Errorf("no version satisfies %s", r)
+	}
+
+	return newest.String(), nil
+}
@@ -0,0 +1,49 @@
+package n
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolvePackageManager(t *testing.T) {
+	root := t.TempDir()
+
+	// no npm to ask the registry with
+	n := &N{rootDir: root, maxAge: DefaultMaxAge, binPath: filepath.Join(root, "missing", "node")}
+
+	if v, err := n.resolvePackageManager("pnpm", "pnpm", "v9.1.0"); err != nil || v != "9.1.0" {
+		t.Fatalf("expected an exact version as is, got %q, %v", v, err)
+	}
+
+	if _, err := n.resolvePackageManager("pnpm", "pnpm", "^9"); err == nil {
+		t.Fatal("expected a range to fail with nothing to resolve it with")
+	}
+
+	// the newest installed version satisfying it
+	for _, dir := range []string{"pnpm@9.1.0", "pnpm@9.12.3", "pnpm@10.0.0", "yarn@9.15.0"} {
+		if err := os.MkdirAll(filepath.Join(root, "package-managers", dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if v, err := n.resolvePackageManager("pnpm", "pnpm", "^9"); err != nil || v != "9.12.3" {
+		t.Fatalf("expected the installed 9.12.3, got %q, %v", v, err)
+	}
+
+	// what the registry said, until it's older than the max age
+	path := filepath.Join(root, "package-managers", "pnpm@9.x.resolved")
+	if err := os.WriteFile(path, []byte("9.15.0"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if v, err := n.resolvePackageManager("pnpm", "pnpm", "9.x"); err != nil || v != "9.15.0" {
+		t.Fatalf("expected the resolved 9.15.0, got %q, %v", v, err)
+	}
+
+	age(t, path)
+
+	if v, err := n.resolvePackageManager("pnpm", "pnpm", "9.x"); err != nil || v != "9.12.3" {
+		t.Fatalf("expected the installed 9.12.3 once the registry's answer is old, got %q, %v", v, err)
+	}
+}
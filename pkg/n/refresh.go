@@ -0,0 +1,329 @@
+package n
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// DefaultMaxAge is how long a downloaded index or schedule is used before it
+// is refreshed unless WithMaxAge says otherwise
+const DefaultMaxAge = 24 * time.Hour
+
+// WithMaxAge refreshes the release index, and the other files downloaded
+// along with it, once they are older than d. Zero refreshes them every time.
+func WithMaxAge(d time.Duration) Option {
+	return func(n *N) {
+		if d >= 0 {
+			n.maxAge = d
+		}
+	}
+}
+
+// WithBackgroundRefresh uses a release index older than the max age as is
+// instead of waiting for it to be refreshed, and sets Resolution().Stale so
+// the caller can refresh it after, see Resolver.Refresh. The index is still
+// refreshed first when the stale copy has no release for the spec.
+func WithBackgroundRefresh(background bool) Option {
+	return func(n *N) {
+		n.background = background
+	}
+}
+
+// client fetches indexes, a mirror that doesn't answer mustn't keep every
+// process waiting on the refresh lock forever
+var client = &http.Client{Timeout: time.Minute}
+
+// errNotModified is what a conditional request gets when the copy it has is
+// still the latest
+var errNotModified = errors.New("not modified")
+
+// companions are the files downloaded along with the release index once
+// they are needed, by path, see Resolver.Refresh
+func (n *N) companions() map[string]fetchFunc {
+	files := map[string]fetchFunc{filepath.Join(n.rootDir, scheduleFile): n.fetchSchedule}
+
+	if len(n.unofficialMirrors) > 0 {
+		files[filepath.Join(n.rootDir, unofficialFile)] = n.fetchUnofficial
+	}
+
+	return files
+}
+
+// validators are what a server said about the copy of a file that was
+// downloaded, so the next download can be skipped if it hasn't changed
+type validators struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+}
+
+// fetchFunc downloads a file, unless it's the one prev describes, in which
+// case it returns errNotModified
+type fetchFunc func(prev validators) ([]byte, validators, error)
+
+// cached returns the content of the file at path, refreshing it first if it
+// is older than the max age. If that fails an older copy is better than
+// nothing, and the refresh isn't tried again until the max age has passed,
+// an unreachable server would have every run wait for it. Offline, nothing
+// is fetched, and WithBackgroundRefresh leaves an older copy to
+// Resolver.Refresh. A copy that isn't json is removed and downloaded again.
+func (n *N) cached(path string, fetch fetchFunc) ([]byte, error) {
+	content, err := n.readCached(path, fetch)
+	if err == nil && !json.Valid(content) {
+		os.Remove(path)
+		content, err = n.readCached(path, fetch)
+	}
+
+	return content, err
+}
+
+// readCached is cached without checking what it reads
+func (n *N) readCached(path string, fetch fetchFunc) ([]byte, error) {
+	if _, ok := usable(path); n.offline || (n.background && ok) {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, ErrOffline
+		}
+
+		return content, nil
+	}
+
+	var refreshErr error
+
+	if stat, err := os.Stat(failedFile(path)); err == nil && time.Since(stat.ModTime()) < n.maxAge {
+		refreshErr = fmt.Errorf("failed to download %s at %s, not trying again before %s", filepath.Base(path), stat.ModTime().Format(time.DateTime), stat.ModTime().Add(n.maxAge).Format(time.DateTime))
+	} else if refreshErr = refresh(path, n.maxAge, fetch); refreshErr != nil {
+		os.WriteFile(failedFile(path), []byte(refreshErr.Error()+"\n"), 0640)
+	} else {
+		os.Remove(failedFile(path))
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if refreshErr != nil {
+			return nil, refreshErr
+		}
+
+		return nil, err
+	}
+
+	return content, nil
+}
+
+// refresh downloads the file at path with fetch unless it's less than maxAge
+// old. Processes refreshing the same file at once wait for the first one
+// instead of all downloading it. The file is replaced whole once the new
+// copy is known to be good, never left half written.
+func refresh(path string, maxAge time.Duration, fetch fetchFunc) error {
+	if fresh(path, maxAge) {
+		return nil
+	}
+
+	unlock, err := lock(path + ".lock")
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	// refreshed by whoever held the lock before
+	if fresh(path, maxAge) {
+		return nil
+	}
+
+	var prev validators
+
+	// the copy is only worth revalidating if there is one
+	if _, ok := usable(path); ok {
+		prev = readValidators(path)
+	}
+
+	content, next, err := fetch(prev)
+	if errors.Is(err, errNotModified) {
+		now := time.Now()
+		return os.Chtimes(path, now, now)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err := writeFile(path, content); err != nil {
+		return err
+	}
+
+	writeValidators(path, next)
+
+	return nil
+}
+
+// fresh reports whether the file at path is less than maxAge old
+func fresh(path string, maxAge time.Duration) bool {
+	age, ok := usable(path)
+	return ok && age < maxAge
+}
+
+// usable reports whether there is a file at path, and how old it is. What's
+// in it is checked once, where it's read, see loadIndex and cached.
+func usable(path string) (time.Duration, bool) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return 0, false
+	}
+
+	return time.Since(stat.ModTime()), true
+}
+
+// lock takes an exclusive lock on the file at path, blocking until it gets
+// it. The lock goes away with the process if it isn't unlocked.
+func lock(path string) (unlock func(), err error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0640)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := unix.Flock(int(f.Fd()), unix.LOCK_EX); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+
+	return func() {
+		unix.Flock(int(f.Fd()), unix.LOCK_UN)
+		f.Close()
+	}, nil
+}
+
+// writeFile replaces the file at path with content through a temporary file
+// renamed over it, so readers see either the old or the new file
+func writeFile(path string, content []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Chmod(0640); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+// failedFile marks the last failed refresh of path, its modification time is
+// when it was
+func failedFile(path string) string {
+	return path + ".failed"
+}
+
+func validatorsFile(path string) string {
+	return path + ".validators"
+}
+
+func readValidators(path string) validators {
+	var v validators
+
+	if content, err := os.ReadFile(validatorsFile(path)); err == nil {
+		json.Unmarshal(content, &v)
+	}
+
+	return v
+}
+
+// writeValidators saves v for the next refresh of path, without them the
+// file is downloaded again, nothing worse
+func writeValidators(path string, v validators) {
+	if v.ETag == "" && v.LastModified == "" {
+		os.Remove(validatorsFile(path))
+		return
+	}
+
+	if content, err := json.Marshal(v); err == nil {
+		writeFile(validatorsFile(path), content)
+	}
+}
+
+// fetchIndex downloads the release index from the first of mirrors that
+// serves one
+func fetchIndex(mirrors []string, prev validators) ([]byte, validators, error) {
+	var errs []error
+
+	for _, mirror := range mirrors {
+		content, next, err := fetchIf(mirror+"/index.json", prev)
+		if err == nil || errors.Is(err, errNotModified) {
+			return content, next, err
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", mirror, err))
+	}
+
+	return nil, validators{}, errors.Join(errs...)
+}
+
+// fetchIf downloads the json document at url, unless prev is of the same url
+// and the server says it hasn't changed since
+func fetchIf(url string, prev validators) ([]byte, validators, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, validators{}, err
+	}
+
+	if prev.URL == url {
+		if prev.ETag != "" {
+			req.Header.Set("If-None-Match", prev.ETag)
+		}
+
+		if prev.LastModified != "" {
+			req.Header.Set("If-Modified-Since", prev.LastModified)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, validators{}, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && prev.URL == url {
+		return nil, prev, errNotModified
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, validators{}, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, validators{}, err
+	}
+
+	// a captive portal or a misconfigured mirror answers with html
+	if !json.Valid(content) {
+		return nil, validators{}, errors.New("not a release index")
+	}
+
+	return content, validators{
+		URL:          url,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}, nil
+}
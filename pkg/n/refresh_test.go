@@ -0,0 +1,276 @@
+package n
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// indexServer serves testCache with an ETag, answering 304 to requests that
+// have it, or fail when it's set
+type indexServer struct {
+	*httptest.Server
+
+	downloads   atomic.Int32
+	revalidated atomic.Int32
+	fail        atomic.Bool
+}
+
+func newIndexServer(t *testing.T, delay time.Duration) *indexServer {
+	t.Helper()
+
+	b, err := json.Marshal(testCache())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &indexServer{}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.fail.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		time.Sleep(delay)
+
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			s.revalidated.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		s.downloads.Add(1)
+		w.Header().Set("ETag", `"v1"`)
+		w.Write(b)
+	}))
+
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func age(t *testing.T, path string) {
+	t.Helper()
+
+	old := time.Now().Add(-2 * DefaultMaxAge)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	root := t.TempDir()
+	s := newIndexServer(t, 0)
+	path := filepath.Join(root, "node_versions.json")
+
+	resolve := func() {
+		t.Helper()
+
+		n, err := NewResolver(root, WithMirror(s.URL)).resolve("^20")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if n.offline || n.versionStr != "v20.18.0" {
+			t.Fatalf("expected v20.18.0 from the index, got %s (offline %v)", n.versionStr, n.offline)
+		}
+	}
+
+	resolve()
+	resolve()
+
+	if s.downloads.Load() != 1 {
+		t.Fatalf("expected the index to be downloaded once, got %d", s.downloads.Load())
+	}
+
+	// a day later it is revalidated, not downloaded again
+	age(t, path)
+	resolve()
+
+	if s.downloads.Load() != 1 || s.revalidated.Load() != 1 {
+		t.Fatalf("expected a revalidation, got %d downloads and %d revalidations", s.downloads.Load(), s.revalidated.Load())
+	}
+
+	if stat, err := os.Stat(path); err != nil || time.Since(stat.ModTime()) > time.Minute {
+		t.Fatalf("expected a revalidated index to be fresh again, %v", err)
+	}
+
+	// a failed refresh leaves the copy alone, and it is used
+	age(t, path)
+	s.fail.Store(true)
+
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := NewResolver(root, WithMirror(s.URL)).resolve("^20")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n.Offline() || n.RefreshError() == nil || n.versionStr != "v20.18.0" {
+		t.Fatalf("expected v20.18.0 from the old index with the refresh error, got %s (offline %v, error %v)", n.versionStr, n.Offline(), n.RefreshError())
+	}
+
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(before) != string(after) {
+		t.Fatal("expected a failed refresh to keep the index")
+	}
+
+	// a fresh but broken copy, e.g. written by an older novm, is replaced
+	s.fail.Store(false)
+
+	if err := os.WriteFile(path, []byte(`[{"version": "v20.18.0"`), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	resolve()
+
+	if s.downloads.Load() != 2 {
+		t.Fatalf("expected a broken index to be downloaded again, got %d downloads", s.downloads.Load())
+	}
+}
+
+func TestRefreshOnce(t *testing.T) {
+	root := t.TempDir()
+	s := newIndexServer(t, 100*time.Millisecond)
+
+	var wg sync.WaitGroup
+
+	errs := make(chan error, 10)
+
+	for range 10 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := NewResolver(root, WithMirror(s.URL)).resolve("^20")
+			errs <- err
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if s.downloads.Load() != 1 {
+		t.Fatalf("expected one download for all of them, got %d", s.downloads.Load())
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, entry := range entries {
+		if name := entry.Name(); name != "node_versions.json" && name != "node_versions.json.lock" && name != "node_versions.json.validators" {
+			t.Errorf("unexpected %s left behind", name)
+		}
+	}
+}
+
+func TestBackgroundRefresh(t *testing.T) {
+	root := t.TempDir()
+	s := newIndexServer(t, 0)
+	path := filepath.Join(root, "node_versions.json")
+
+	// a day old copy from before 20.18.0 was out
+	b, err := json.Marshal(testCache()[3:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	age(t, path)
+
+	r := NewResolver(root, WithMirror(s.URL), WithBackgroundRefresh(true))
+
+	n, err := r.resolve("^20")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n.versionStr != "v20.18.0" || !n.Resolution().Stale {
+		t.Fatalf("expected v20.18.0 from the stale copy, got %s (stale %v)", n.versionStr, n.Resolution().Stale)
+	}
+
+	if s.downloads.Load() != 0 {
+		t.Fatal("expected the stale copy to be used without refreshing it")
+	}
+
+	// it has nothing for ^22, that is worth waiting for
+	if n, err = r.resolve("^22"); err != nil {
+		t.Fatal(err)
+	}
+
+	if n.versionStr != "v22.11.0" || n.Resolution().Stale || s.downloads.Load() != 1 {
+		t.Fatalf("expected v22.11.0 from a refreshed index, got %s (stale %v, %d downloads)", n.versionStr, n.Resolution().Stale, s.downloads.Load())
+	}
+
+	// specs resolved after use the refreshed index
+	if n, err = r.resolve("^20"); err != nil || n.Resolution().Stale {
+		t.Fatalf("expected the refreshed index, got %v (stale %v)", err, n.Resolution().Stale)
+	}
+
+	// what a detached refresh does
+	age(t, path)
+
+	if err := NewResolver(root, WithMirror(s.URL), WithBackgroundRefresh(true)).Refresh(""); err != nil {
+		t.Fatal(err)
+	}
+
+	if !fresh(path, DefaultMaxAge) || s.revalidated.Load() != 1 {
+		t.Fatalf("expected Refresh to revalidate the index, got %d revalidations", s.revalidated.Load())
+	}
+
+	if err := r.Refresh("nightlies"); err == nil {
+		t.Fatal("expected an unknown channel to fail")
+	}
+}
+
+func TestMaxAge(t *testing.T) {
+	root := t.TempDir()
+	s := newIndexServer(t, 0)
+	path := filepath.Join(root, "node_versions.json")
+
+	if _, err := NewResolver(root, WithMirror(s.URL)).resolve("^20"); err != nil {
+		t.Fatal(err)
+	}
+
+	hourAgo := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(path, hourAgo, hourAgo); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, d := range []time.Duration{DefaultMaxAge, 2 * time.Hour, 30 * time.Minute, 0} {
+		if _, err := NewResolver(root, WithMirror(s.URL), WithMaxAge(d)).resolve("^20"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// an hour is too old for 30 minutes, anything is for 0
+	if s.revalidated.Load() != 2 {
+		t.Fatalf("expected 2 revalidations, got %d", s.revalidated.Load())
+	}
+}
@@ -0,0 +1,128 @@
+package n
+
+import (
+	"fmt"
+	"time"
+
+	semverv3 "github.com/Masterminds/semver/v3"
+)
+
+// Filter narrows down the releases Resolver.Releases lists, the zero Filter
+// keeps them all
+type Filter struct {
+	// Constraint is a version range the releases satisfy, e.g. ">=18 <22"
+	Constraint string
+
+	// Lts keeps the releases of an lts line, named the way lts specs do,
+	// e.g. "iron" or "-1", "*" keeps those of every line
+	Lts string
+
+	// Major keeps the releases of a major line, 0 keeps every line
+	Major uint64
+
+	// Security keeps security releases
+	Security bool
+
+	// Since keeps the releases out on or after it
+	Since time.Time
+}
+
+// ListedRelease is a release as Resolver.Releases lists it, with what this
+// platform has of it
+type ListedRelease struct {
+	Release
+
+	// ArchiveType is the build this platform needs, e.g. linux-x64, Build
+	// whether the release has one and Unofficial whether it's only an
+	// unofficial build
+	ArchiveType string `json:"archiveType"`
+	Build       bool   `json:"build"`
+	Unofficial  bool   `json:"unofficial,omitempty"`
+
+	// Installed is set when the release is installed under rootDir
+	Installed bool `json:"installed"`
+}
+
+// Releases lists the releases in the release index that f keeps, newest
+// first. Offline it fails with ErrOffline, only installed versions are known.
+func (r *Resolver) Releases(f Filter) ([]ListedRelease, error) {
+	n := r.newN("")
+
+	idx, err := r.index(n)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.offline {
+		return nil, ErrOffline
+	}
+
+	keep, err := f.keep(n.cache)
+	if err != nil {
+		return nil, err
+	}
+
+	var releases []ListedRelease
+
+	for _, release := range n.cache {
+		v, err := semverv3.NewVersion(release.Version)
+		if err != nil || !keep(release, v) {
+			continue
+		}
+
+		// old darwin releases only have x64 builds
+		n.version = v
+		n.arch = n.getNodeJsArch()
+
+		listed := ListedRelease{Release: release.release(), ArchiveType: n.getArchiveType()}
+		listed.Build, listed.Unofficial = n.build(release, listed.ArchiveType)
+		listed.Installed = n.isInstalled(release)
+
+		releases = append(releases, listed)
+	}
+
+	// fetched for releases without an official build, if at all
+	idx.unofficial = n.unofficialCache
+
+	return releases, nil
+}
+
+// keep returns whether a release of cache is one f keeps
+func (f Filter) keep(cache nCache) (func(release nCacheItem, v *semverv3.Version) bool, error) {
+	var constraint *nodeRange
+
+	if f.Constraint != "" {
+		var err error
+		if constraint, err = parseRange(f.Constraint, true); err != nil {
+			return nil, fmt.Errorf("invalid version range %q: %w", f.Constraint, err)
+		}
+	}
+
+	codename := f.Lts
+
+	if f.Lts != "" && f.Lts != "*" {
+		var err error
+		if codename, err = cache.ltsCodename(f.Lts); err != nil {
+			return nil, err
+		}
+	}
+
+	return func(release nCacheItem, v *semverv3.Version) bool {
+		switch {
+		case constraint != nil && !constraint.Contains(v):
+			return false
+		case codename == "*" && release.ltsName() == "":
+			return false
+		case codename != "" && codename != "*" && release.ltsName() != codename:
+			return false
+		case f.Major != 0 && v.Major() != f.Major:
+			return false
+		case f.Security && !release.Security:
+			return false
+		case !f.Since.IsZero() && release.release().Date.Before(f.Since):
+			return false
+		}
+
+		return true
+	}, nil
+}
@@ -0,0 +1,81 @@
+package n
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestReleases(t *testing.T) {
+	root := t.TempDir()
+	installFake(t, root, "v20.11.0")
+
+	// a release without a build for this platform
+	cache := append(testCache(), nCacheItem{Version: "v16.0.0", Date: "2021-04-20", Files: []string{"aix-ppc64"}, Lts: false})
+
+	b, err := json.Marshal(cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "node_versions.json"), b, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewResolver(root, WithMirror("http://127.0.0.1:1"), WithUnofficialBuilds())
+
+	since, _ := time.Parse(time.DateOnly, "2024-10-01")
+
+	cases := []struct {
+		filter   Filter
+		expected []string
+	}{
+		{Filter{}, []string{"v23.1.0", "v22.11.0", "v22.10.0", "v20.18.0", "v20.11.1", "v20.11.0", "v18.20.4", "v18.17.0", "v16.0.0"}},
+		{Filter{Constraint: ">=18.20 <21"}, []string{"v20.18.0", "v20.11.1", "v20.11.0", "v18.20.4"}},
+		{Filter{Lts: "*"}, []string{"v22.11.0", "v20.18.0", "v20.11.1", "v20.11.0", "v18.20.4", "v18.17.0"}},
+		{Filter{Lts: "Iron"}, []string{"v20.18.0", "v20.11.1", "v20.11.0"}},
+		{Filter{Lts: "-2", Constraint: "<18.20"}, []string{"v18.17.0"}},
+		{Filter{Major: 22}, []string{"v22.11.0", "v22.10.0"}},
+		{Filter{Security: true}, []string{"v20.11.1"}},
+		{Filter{Since: since}, []string{"v20.18.0"}},
+	}
+
+	for _, c := range cases {
+		releases, err := r.Releases(c.filter)
+		if err != nil {
+			t.Fatalf("%+v: %v", c.filter, err)
+		}
+
+		var versions []string
+		for _, release := range releases {
+			versions = append(versions, release.Version)
+		}
+
+		if !slices.Equal(versions, c.expected) {
+			t.Errorf("%+v: expected %v, got %v", c.filter, c.expected, versions)
+		}
+	}
+
+	releases, err := r.Releases(Filter{Constraint: "20.11.0 || 16"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(releases) != 2 || !releases[0].Installed || !releases[0].Build || releases[1].Installed || releases[1].Build {
+		t.Fatalf("expected v20.11.0 installed and no build of v16.0.0, got %+v", releases)
+	}
+
+	for _, f := range []Filter{{Constraint: "x.y"}, {Lts: "nope"}} {
+		if _, err := r.Releases(f); err == nil {
+			t.Errorf("%+v: expected an error", f)
+		}
+	}
+
+	if _, err := NewResolver(root, WithOffline(true)).Releases(Filter{}); !errors.Is(err, ErrOffline) {
+		t.Fatalf("expected ErrOffline, got %v", err)
+	}
+}
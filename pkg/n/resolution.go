@@ -0,0 +1,68 @@
+package n
+
+// Resolution describes how NewNodeManager turned a version spec into a
+// concrete release
+type Resolution struct {
+	// Spec is the version string NewNodeManager was given
+	Spec string `json:"spec"`
+
+	// Aliases is how Spec was expanded if it is an alias, the aliases it went
+	// through and the spec they point to, e.g. [work legacy-api ^18]
+	Aliases []string `json:"aliases,omitempty"`
+
+	// Channel is the prerelease channel Spec named, empty for releases
+	Channel string `json:"channel,omitempty"`
+
+	// Constraint is Spec as parsed, empty for latest/lts
+	Constraint string `json:"constraint,omitempty"`
+
+	// Offline is set when only installed versions were considered, because
+	// the release index couldn't be fetched or WithOffline asked for it
+	Offline bool `json:"offline,omitempty"`
+
+	// Stale is set when the release index was older than the max age and
+	// used as is, see WithBackgroundRefresh. It is due for a refresh.
+	Stale bool `json:"stale,omitempty"`
+
+	// ArchiveType is the release file this platform needs, e.g. linux-x64
+	ArchiveType string `json:"archiveType"`
+
+	// Strategy picked among the releases satisfying Constraint, empty for
+	// latest/lts
+	Strategy Strategy `json:"strategy,omitempty"`
+
+	// Skipped are the releases that matched Spec but were passed over, in
+	// the order Strategy tried them
+	Skipped []Candidate `json:"skipped,omitempty"`
+
+	// Unofficial is set when the official release has no build for this
+	// platform and an unofficial build is used instead
+	Unofficial bool `json:"unofficial,omitempty"`
+
+	// SecurityRelease is the newest security release of Version's major line
+	// that is newer than Version, empty if there is none, Version is
+	// vulnerable. WithSecurityUpgrade only upgrades within the minor line,
+	// and as far as the range resolved allows.
+	SecurityRelease string `json:"securityRelease,omitempty"`
+
+	// UpgradedFrom is the release picked before WithSecurityUpgrade moved it
+	// to SecurityRelease
+	UpgradedFrom string `json:"upgradedFrom,omitempty"`
+
+	// Version is the release that was picked
+	Version string `json:"version"`
+}
+
+type Candidate struct {
+	Version string `json:"version"`
+	Reason  string `json:"reason"`
+}
+
+func (r *Resolution) skip(version, reason string) {
+	r.Skipped = append(r.Skipped, Candidate{Version: version, Reason: reason})
+}
+
+// Resolution returns how the version of n was picked
+func (n *N) Resolution() Resolution {
+	return n.resolution
+}
@@ -0,0 +1,298 @@
+package n
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	semverv3 "github.com/Masterminds/semver/v3"
+)
+
+// Release is a nodejs release as the release index describes it
+type Release struct {
+	Version string    `json:"version"`
+	Date    time.Time `json:"date"`
+
+	// Files are the builds of the release, e.g. linux-x64 or osx-arm64-tar
+	Files []string `json:"files"`
+
+	// Lts is the codename of the lts line the release is part of, empty if
+	// it isn't an lts release
+	Lts string `json:"lts,omitempty"`
+
+	// Npm and V8 are the versions the release ships with
+	Npm string `json:"npm,omitempty"`
+	V8  string `json:"v8,omitempty"`
+
+	// Security is set for releases that fix vulnerabilities
+	Security bool `json:"security"`
+}
+
+func (item nCacheItem) release() Release {
+	lts, _ := item.Lts.(string)
+	date, _ := time.Parse(time.DateOnly, item.Date)
+
+	return Release{
+		Version:  item.Version,
+		Date:     date,
+		Files:    item.Files,
+		Lts:      lts,
+		Npm:      item.Npm,
+		V8:       item.V8,
+		Security: item.Security,
+	}
+}
+
+// ErrNoMatchingRelease is what a *NoMatchError is
+var ErrNoMatchingRelease = errors.New("no matching release")
+
+// NoMatchError is returned when no release satisfying a spec has a build for
+// this platform
+type NoMatchError struct {
+	Spec        string
+	ArchiveType string
+
+	// Rejected are the releases that satisfied Spec but were passed over,
+	// and why
+	Rejected []Candidate
+}
+
+func (e *NoMatchError) Error() string {
+	if len(e.Rejected) == 0 {
+		return fmt.Sprintf("no release found for version: %q", e.Spec)
+	}
+
+	return fmt.Sprintf("no release found for version %q with a %s build, %d releases rejected", e.Spec, e.ArchiveType, len(e.Rejected))
+}
+
+// Is makes a *NoMatchError both ErrNoMatchingRelease and, as it used to be,
+// ErrNodeVersionNotFound
+func (e *NoMatchError) Is(target error) bool {
+	return target == ErrNoMatchingRelease || target == ErrNodeVersionNotFound
+}
+
+// Resolver turns version specs into releases, the way NewNodeManager does,
+// without installing anything or setting up an N to run it. It reads the
+// cached release index under rootDir, or fetches and caches it, once per
+// channel for all the specs it resolves.
+type Resolver struct {
+	rootDir string
+	opts    []Option
+
+	// indexes are the release indexes loaded so far, by channel
+	indexes map[string]*index
+}
+
+type index struct {
+	cache      nCache
+	offline    bool
+	stale      bool
+	refreshErr error
+	unofficial nCache
+}
+
+// NewResolver returns a Resolver taking the same options as NewNodeManager
+func NewResolver(rootDir string, opts ...Option) *Resolver {
+	return &Resolver{rootDir: rootDir, opts: opts, indexes: map[string]*index{}}
+}
+
+// Resolve returns the release spec stands for, following aliases, see
+// SetAlias. A spec no release satisfies fails with a *NoMatchError.
+//
+// It isn't a pure lookup: the first spec of a channel creates rootDir if
+// needed and downloads the release index into it when the cached copy is
+// older than the max age, or broken, see WithMaxAge and WithOffline. The
+// unofficial builds index is downloaded the same way when a release has no
+// official build for this platform.
+func (r *Resolver) Resolve(spec string) (Release, error) {
+	n, err := r.resolve(spec)
+	if err != nil {
+		return Release{}, err
+	}
+
+	return n.Release(), nil
+}
+
+// newN returns an N with the defaults and the options of r
+func (r *Resolver) newN(spec string) *N {
+	n := &N{
+		rootDir:    r.rootDir,
+		versionStr: spec,
+		mirrors:    []string{DefaultMirror},
+		strategy:   StrategyNewest,
+		maxAge:     DefaultMaxAge,
+
+		unofficialMirrors: []string{DefaultUnofficialMirror},
+		schedule:          DefaultSchedule,
+	}
+
+	for _, opt := range r.opts {
+		opt(n)
+	}
+
+	return n
+}
+
+// Refresh refreshes the release index of channel, empty for releases, if it
+// is older than the max age, waiting for it even with WithBackgroundRefresh.
+// With the release index go the schedule and the unofficial builds index, if
+// they were ever downloaded. The specs resolved after use the refreshed index.
+func (r *Resolver) Refresh(channel string) error {
+	if channel != "" && !slices.Contains(Channels, channel) {
+		return fmt.Errorf("unknown channel %q, expected one of %s", channel, strings.Join(Channels, ", "))
+	}
+
+	n := r.newN("")
+	if n.offline {
+		return ErrOffline
+	}
+
+	errs := []error{r.refreshIndex(channel)}
+
+	if channel == "" {
+		for path, fetch := range n.companions() {
+			if _, err := os.Stat(path); err == nil {
+				errs = append(errs, refresh(path, n.maxAge, fetch))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// refreshIndex refreshes the release index of channel and loads it for the
+// specs resolved after
+func (r *Resolver) refreshIndex(channel string) error {
+	n := r.newN("")
+
+	if channel != "" {
+		mirrors, err := channelMirrors(n.mirrors, channel)
+		if err != nil {
+			return err
+		}
+
+		n.channel, n.mirrors = channel, mirrors
+	}
+
+	if err := n.refreshIndex(); err != nil {
+		return err
+	}
+
+	if err := n.loadIndex(n.cacheFile()); err != nil {
+		return err
+	}
+
+	r.indexes[channel] = &index{cache: n.cache}
+
+	return nil
+}
+
+// index loads the release index of the channel of n into it, once per
+// channel
+func (r *Resolver) index(n *N) (*index, error) {
+	idx, ok := r.indexes[n.channel]
+	if ok {
+		n.cache, n.offline, n.stale, n.refreshErr, n.unofficialCache = idx.cache, idx.offline, idx.stale, idx.refreshErr, idx.unofficial
+		return idx, nil
+	}
+
+	if err := n.initCache(); err != nil {
+		return nil, err
+	}
+
+	idx = &index{cache: n.cache, offline: n.offline, stale: n.stale, refreshErr: n.refreshErr}
+	r.indexes[n.channel] = idx
+
+	return idx, nil
+}
+
+// resolve returns an N with spec resolved, not ready to run anything
+func (r *Resolver) resolve(spec string) (*N, error) {
+	n := r.newN(spec)
+
+	if !n.strategy.valid() {
+		return nil, fmt.Errorf("unknown resolution strategy %q", n.strategy)
+	}
+
+	version, aliases, err := ExpandAlias(r.rootDir, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	if channel, v, ok := parseChannel(version); ok {
+		mirrors, err := channelMirrors(n.mirrors, channel)
+		if err != nil {
+			return nil, err
+		}
+
+		n.channel, version, n.mirrors = channel, v, mirrors
+
+		// unofficial builds are of releases only
+		n.unofficialMirrors = nil
+	}
+
+	idx, err := r.index(n)
+	if err != nil {
+		return nil, err
+	}
+
+	n.resolution.Spec = spec
+	if len(aliases) > 0 {
+		n.resolution.Aliases = append(aliases, version)
+	}
+	n.resolution.Channel = n.channel
+	n.resolution.Offline = n.offline
+	n.resolution.Stale = n.stale
+
+	err = n.resolve(version)
+
+	// fetched while resolving, if at all
+	idx.unofficial = n.unofficialCache
+
+	// the stale index may predate the release spec needs, that one is worth
+	// waiting for
+	if err != nil && n.stale && r.refreshIndex(n.channel) == nil {
+		return r.resolve(spec)
+	}
+
+	if err != nil {
+		if n.offline {
+			return nil, fmt.Errorf("%w: %w", ErrOffline, err)
+		}
+
+		return nil, err
+	}
+
+	n.checkSecurity()
+
+	n.resolution.Version = n.versionStr
+
+	return n, nil
+}
+
+// Release returns the release n was resolved to
+func (n *N) Release() Release {
+	for _, item := range n.cache {
+		if item.Version == n.versionStr {
+			return item.release()
+		}
+	}
+
+	return Release{Version: n.versionStr}
+}
+
+// match reports whether v satisfies spec, and whether it is the very version
+// spec names
+func match(spec SemverManager, v *semverv3.Version) (ok, exact bool) {
+	switch spec := spec.(type) {
+	case *semverv3.Version:
+		return spec.Equal(v), spec.Equal(v)
+	case *nodeRange:
+		return spec.Contains(v), false
+	}
+
+	return false, false
+}
@@ -0,0 +1,95 @@
+package n
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestResolver(t *testing.T) {
+	root := t.TempDir()
+	writeCache(t, root)
+
+	r := NewResolver(root, WithMirror("http://127.0.0.1:1"))
+
+	release, err := r.Resolve("^20")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Release{
+		Version: "v20.18.0",
+		Date:    time.Date(2024, 10, 3, 0, 0, 0, 0, time.UTC),
+		Npm:     "10.8.2",
+		V8:      "11.3.244.8-node.23",
+		Lts:     "Iron",
+	}
+
+	if release.Version != expected.Version || !release.Date.Equal(expected.Date) || release.Npm != expected.Npm || release.V8 != expected.V8 || release.Lts != expected.Lts || len(release.Files) == 0 {
+		t.Fatalf("expected %+v, got %+v", expected, release)
+	}
+
+	// the index is read once
+	if err := os.Remove(filepath.Join(root, "node_versions.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	if release, err = r.Resolve("hydrogen"); err != nil || release.Version != "v18.20.4" {
+		t.Fatalf("expected v18.20.4, got %s (%v)", release.Version, err)
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 0 {
+		t.Fatalf("expected nothing to be installed, found %s", entries[0].Name())
+	}
+}
+
+func TestNoMatchingRelease(t *testing.T) {
+	root := t.TempDir()
+
+	b, err := json.Marshal(nCache{
+		{Version: "v22.1.0", Files: []string{"aix-ppc64"}},
+		{Version: "v22.0.0", Files: []string{"aix-ppc64"}},
+		{Version: "v20.11.0", Files: []string{"aix-ppc64"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "node_versions.json"), b, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if runtime.GOOS == "aix" {
+		t.Skip("every release has a build")
+	}
+
+	r := NewResolver(root, WithMirror("http://127.0.0.1:1"), WithUnofficialBuilds())
+
+	for spec, rejected := range map[string][]string{"^22": {"v22.1.0", "v22.0.0"}, "latest": {"v22.1.0", "v22.0.0", "v20.11.0"}, "^24": nil} {
+		_, err := r.Resolve(spec)
+
+		if !errors.Is(err, ErrNoMatchingRelease) || !errors.Is(err, ErrNodeVersionNotFound) {
+			t.Fatalf("%s: expected ErrNoMatchingRelease, got %v", spec, err)
+		}
+
+		var noMatch *NoMatchError
+		if !errors.As(err, &noMatch) || len(noMatch.Rejected) != len(rejected) {
+			t.Fatalf("%s: expected %v to be rejected, got %+v", spec, rejected, noMatch)
+		}
+
+		for i, c := range noMatch.Rejected {
+			if c.Version != rejected[i] || c.Reason == "" {
+				t.Fatalf("%s: expected %v to be rejected, got %+v", spec, rejected, noMatch.Rejected)
+			}
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package n
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	semverv3 "github.com/Masterminds/semver/v3"
+)
+
+// DefaultSchedule is where the release schedule of the nodejs release lines
+// is downloaded from
+const DefaultSchedule = "https://raw.githubusercontent.com/nodejs/Release/main/schedule.json"
+
+// Phase is where a release line is in its life
+type Phase string
+
+const (
+	// PhasePending lines haven't had a release yet
+	PhasePending Phase = "pending"
+	// PhaseCurrent lines get new features
+	PhaseCurrent Phase = "current"
+	// PhaseActiveLTS lines get bug and security fixes
+	PhaseActiveLTS Phase = "active-lts"
+	// PhaseMaintenance lines only get critical and security fixes
+	PhaseMaintenance Phase = "maintenance"
+	// PhaseEOL lines don't get fixes anymore
+	PhaseEOL Phase = "eol"
+)
+
+// Support describes the release line of a version
+type Support struct {
+	// Line is the release line, e.g. v20, or v0.12 before 1.0
+	Line     string `json:"line"`
+	Codename string `json:"codename,omitempty"`
+
+	Phase Phase `json:"phase"`
+
+	// End is when the line reaches its end of life
+	End time.Time `json:"end"`
+}
+
+// Until returns how long is left until the line reaches its end of life,
+// negative once it has
+func (s Support) Until(now time.Time) time.Duration {
+	return s.End.Sub(now)
+}
+
+// ErrUnscheduled is returned by Support for versions the schedule doesn't
+// have a line for
+var ErrUnscheduled = errors.New("release line not in the release schedule")
+
+// WithSchedule downloads the release schedule from url instead of
+// DefaultSchedule
+func WithSchedule(url string) Option {
+	return func(n *N) {
+		if url != "" {
+			n.schedule = url
+		}
+	}
+}
+
+// scheduleFile is where the release schedule is cached, under the root dir
+const scheduleFile = "schedule.json"
+
+func (n *N) fetchSchedule(prev validators) ([]byte, validators, error) {
+	return fetchIf(n.schedule, prev)
+}
+
+type scheduleLine struct {
+	Start       string `json:"start"`
+	Lts         string `json:"lts"`
+	Maintenance string `json:"maintenance"`
+	End         string `json:"end"`
+	Codename    string `json:"codename"`
+}
+
+// Support returns where the release line of the resolved version is in its
+// life, going by the release schedule. The schedule is downloaded the first
+// time and cached for a day, like the release index.
+func (n *N) Support() (Support, error) {
+	return n.supportAt(time.Now())
+}
+
+func (n *N) supportAt(now time.Time) (Support, error) {
+	v, err := semverv3.NewVersion(n.versionStr)
+	if err != nil {
+		return Support{}, err
+	}
+
+	line := fmt.Sprintf("v%d", v.Major())
+	if v.Major() == 0 {
+		line = fmt.Sprintf("v0.%d", v.Minor())
+	}
+
+	content, err := n.cached(filepath.Join(n.rootDir, scheduleFile), n.fetchSchedule)
+	if err != nil {
+		return Support{}, fmt.Errorf("failed to get the release schedule: %w", err)
+	}
+
+	var schedule map[string]scheduleLine
+	if err := json.Unmarshal(content, &schedule); err != nil {
+		return Support{}, fmt.Errorf("failed to parse the release schedule: %w", err)
+	}
+
+	l, ok := schedule[line]
+	if !ok {
+		return Support{}, fmt.Errorf("%s: %w", line, ErrUnscheduled)
+	}
+
+	s := Support{Line: line, Codename: l.Codename}
+
+	if s.End, err = time.Parse(time.DateOnly, l.End); err != nil {
+		return Support{}, fmt.Errorf("%s: invalid end of life %q", line, l.End)
+	}
+
+	// the last phase that has started, lines without an lts phase go from
+	// current to maintenance
+	s.Phase = PhasePending
+
+	for _, phase := range []struct {
+		phase Phase
+		date  string
+	}{
+		{PhaseCurrent, l.Start},
+		{PhaseActiveLTS, l.Lts},
+		{PhaseMaintenance, l.Maintenance},
+		{PhaseEOL, l.End},
+	} {
+		date, err := time.Parse(time.DateOnly, phase.date)
+		if err != nil {
+			continue
+		}
+
+		if !now.Before(date) {
+			s.Phase = phase.phase
+		}
+	}
+
+	return s, nil
+}
+
+// lineName is how the release line of s is usually called, e.g. v20 (Iron)
+func (s Support) lineName() string {
+	if s.Codename == "" {
+		return s.Line
+	}
+
+	return s.Line + " (" + s.Codename + ")"
+}
+
+// String describes s, e.g. "v18 (Hydrogen) in maintenance, end-of-life on 2025-04-30"
+func (s Support) String() string {
+	end := s.End.Format(time.DateOnly)
+
+	switch s.Phase {
+	case PhaseEOL:
+		return fmt.Sprintf("%s reached end-of-life on %s", s.lineName(), end)
+	case PhaseActiveLTS:
+		return fmt.Sprintf("%s in active LTS, end-of-life on %s", s.lineName(), end)
+	}
+
+	return fmt.Sprintf("%s in %s, end-of-life on %s", s.lineName(), s.Phase, end)
+}
@@ -0,0 +1,109 @@
+package n
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testSchedule = `{
+  "v0.12": {"start": "2015-02-06", "end": "2016-12-31"},
+  "v18": {"start": "2022-04-19", "lts": "2022-10-25", "maintenance": "2023-10-18", "end": "2025-04-30", "codename": "Hydrogen"},
+  "v20": {"start": "2023-04-18", "lts": "2023-10-24", "maintenance": "2024-10-22", "end": "2026-04-30", "codename": "Iron"},
+  "v22": {"start": "2024-04-24", "lts": "2024-10-29", "maintenance": "2025-10-21", "end": "2027-04-30", "codename": "Jod"},
+  "v23": {"start": "2024-10-16", "maintenance": "2025-04-01", "end": "2025-06-01", "codename": ""}
+}`
+
+func TestSupport(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testSchedule))
+	}))
+	defer s.Close()
+
+	root := t.TempDir()
+	now := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	cases := map[string]struct {
+		phase Phase
+		end   string
+	}{
+		"v0.12.18": {PhaseEOL, "2016-12-31"},
+		"v18.20.4": {PhaseMaintenance, "2025-04-30"},
+		"v20.11.0": {PhaseMaintenance, "2026-04-30"},
+		"v22.11.0": {PhaseActiveLTS, "2027-04-30"},
+		"v23.1.0":  {PhaseCurrent, "2025-06-01"},
+	}
+
+	for version, c := range cases {
+		n := &N{versionStr: version, rootDir: root, schedule: s.URL}
+
+		support, err := n.supportAt(now)
+		if err != nil {
+			t.Fatalf("%s: %v", version, err)
+		}
+
+		if support.Phase != c.phase || support.End.Format(time.DateOnly) != c.end {
+			t.Fatalf("%s: expected %s until %s, got %+v", version, c.phase, c.end, support)
+		}
+	}
+
+	n := &N{versionStr: "v18.20.4", rootDir: root, schedule: s.URL}
+	if support, _ := n.supportAt(time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)); support.Phase != PhaseEOL || support.String() != "v18 (Hydrogen) reached end-of-life on 2025-04-30" {
+		t.Fatalf("expected v18 to be past its end of life, got %s", support)
+	}
+
+	if _, err := (&N{versionStr: "v16.20.2", rootDir: root, schedule: s.URL}).supportAt(now); !errors.Is(err, ErrUnscheduled) {
+		t.Fatalf("expected ErrUnscheduled for a line missing from the schedule, got %v", err)
+	}
+
+	// a stale copy is used when the schedule can't be fetched
+	old := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(filepath.Join(root, "schedule.json"), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	n = &N{versionStr: "v20.11.0", rootDir: root, schedule: "http://127.0.0.1:1"}
+	if support, err := n.supportAt(now); err != nil || support.Phase != PhaseMaintenance {
+		t.Fatalf("expected the cached schedule to be used, got %+v (%v)", support, err)
+	}
+
+	// a schedule that can't be downloaded isn't tried again on every run
+	var hits atomic.Int32
+
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		http.Error(w, "blocked", http.StatusForbidden)
+	}))
+	defer down.Close()
+
+	root = t.TempDir()
+
+	for range 3 {
+		n := &N{versionStr: "v20.11.0", rootDir: root, schedule: down.URL, maxAge: DefaultMaxAge}
+		if _, err := n.supportAt(now); err == nil {
+			t.Fatal("expected the schedule not to be found")
+		}
+	}
+
+	if hits.Load() != 1 {
+		t.Fatalf("expected one attempt, got %d", hits.Load())
+	}
+
+	if err := os.Chtimes(filepath.Join(root, "schedule.json.failed"), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	n = &N{versionStr: "v20.11.0", rootDir: root, schedule: s.URL, maxAge: DefaultMaxAge}
+	if _, err := n.supportAt(now); err != nil || hits.Load() != 1 {
+		t.Fatalf("expected the schedule to be downloaded a day later, got %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "schedule.json.failed")); !os.IsNotExist(err) {
+		t.Fatalf("expected the failure to be forgotten, got %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package n
+
+import (
+	semverv3 "github.com/Masterminds/semver/v3"
+)
+
+// WithSecurityUpgrade moves the resolved version to the newest security
+// release of its minor line, if there is one newer than it that the spec
+// still allows, e.g. 18.18.0 to 18.18.2. Resolution().UpgradedFrom says when
+// it happened.
+func WithSecurityUpgrade(upgrade bool) Option {
+	return func(n *N) {
+		n.securityUpgrade = upgrade
+	}
+}
+
+// checkSecurity upgrades the resolved version to the newest security release
+// of its minor line the range resolved allows, if asked to, then records the
+// newest security release of its major line that is still newer. Releases
+// without a build for this platform don't count, there is nothing to upgrade
+// to.
+func (n *N) checkSecurity() {
+	if n.channel != "" {
+		return
+	}
+
+	current, err := semverv3.NewVersion(n.versionStr)
+	if err != nil {
+		return
+	}
+
+	archiveType := n.getArchiveType()
+
+	// newest first, the first one found is the newest
+	for _, release := range n.cache {
+		if !release.Security {
+			continue
+		}
+
+		v, err := semverv3.NewVersion(release.Version)
+		if err != nil || v.Major() != current.Major() || !v.GreaterThan(current) {
+			continue
+		}
+
+		found, unofficial := n.build(release, archiveType)
+		if !found {
+			continue
+		}
+
+		if n.resolution.SecurityRelease == "" {
+			n.resolution.SecurityRelease = release.Version
+		}
+
+		// a newer minor brings more than the fix
+		if !n.securityUpgrade || n.resolution.UpgradedFrom != "" || v.Minor() != current.Minor() {
+			continue
+		}
+
+		// a range is what the project accepts, an older security release
+		// may still be in it. An exact version is what upgrading is for.
+		if r, ok := n.version.(*nodeRange); ok && !r.Contains(v) {
+			continue
+		}
+
+		n.resolution.UpgradedFrom = n.versionStr
+		n.resolution.Unofficial = unofficial
+
+		n.versionStr = release.Version
+	}
+
+	// the upgrade fixes what the releases up to it do
+	if n.resolution.SecurityRelease == n.versionStr {
+		n.resolution.SecurityRelease = ""
+	}
+}
@@ -0,0 +1,109 @@
+package n
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSecurity(t *testing.T) {
+	root := t.TempDir()
+	writeCache(t, root)
+
+	unreachable := WithMirror("http://127.0.0.1:1")
+
+	cases := []struct {
+		spec     string
+		upgrade  bool
+		expected string
+		security string
+		from     string
+	}{
+		{"20.11.0", false, "v20.11.0", "v20.11.1", ""},
+		{"20.11.0", true, "v20.11.1", "", "v20.11.0"},
+		{"~20.11", true, "v20.11.1", "", ""},
+		{"20.18.0", true, "v20.18.0", "", ""},
+		{"18.17.0", true, "v18.17.0", "", ""},
+	}
+
+	check := func(c struct {
+		spec     string
+		upgrade  bool
+		expected string
+		security string
+		from     string
+	}) {
+		t.Helper()
+
+		n, err := NewNodeManager(false, c.spec, root, unreachable, WithSecurityUpgrade(c.upgrade))
+		if err != nil {
+			t.Fatalf("%s: %v", c.spec, err)
+		}
+
+		r := n.Resolution()
+		if r.Version != c.expected || r.SecurityRelease != c.security {
+			t.Fatalf("%s (upgrade=%v): expected %s with security release %q, got %s with %q", c.spec, c.upgrade, c.expected, c.security, r.Version, r.SecurityRelease)
+		}
+
+		if r.UpgradedFrom != c.from {
+			t.Fatalf("%s: expected an upgrade from %q, got %q", c.spec, c.from, r.UpgradedFrom)
+		}
+	}
+
+	for _, c := range cases {
+		check(c)
+	}
+
+	// security releases of a newer minor line, warned about but not upgraded
+	// to, and of the same line but out of the range
+	cache := testCache()
+	cache = append(cache[:4], append(nCache{
+		{Version: "v20.12.0", Files: cache[0].Files, Lts: "Iron", Security: true},
+		{Version: "v20.11.3", Files: cache[0].Files, Lts: "Iron", Security: true},
+		{Version: "v20.11.2", Files: cache[0].Files, Lts: "Iron", Security: true},
+	}, cache[4:]...)...)
+	cache = append(cache[:len(cache)-1], nCache{
+		{Version: "v18.18.2", Files: cache[0].Files, Lts: "Hydrogen", Security: true},
+		cache[len(cache)-1],
+	}...)
+
+	b, err := json.Marshal(cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "node_versions.json"), b, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases = []struct {
+		spec     string
+		upgrade  bool
+		expected string
+		security string
+		from     string
+	}{
+		{"20.11.0", true, "v20.11.3", "v20.12.0", "v20.11.0"},
+		{"20.11.0", false, "v20.11.0", "v20.12.0", ""},
+		{"~20.11", true, "v20.11.3", "v20.12.0", ""},
+		{">=20.11.0 <20.11.3", true, "v20.11.2", "v20.12.0", ""},
+		{"20.11.0 || 20.11.1", true, "v20.11.1", "v20.12.0", ""},
+		{"18.17.0", false, "v18.17.0", "v18.18.2", ""},
+		{"18.17.0", true, "v18.17.0", "v18.18.2", ""},
+	}
+
+	for _, c := range cases {
+		check(c)
+	}
+
+	// the newest security release the range allows
+	n, err := NewNodeManager(false, ">=20.11.0 <20.11.3", root, unreachable, WithSecurityUpgrade(true), WithStrategy(StrategyOldestSatisfying))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r := n.Resolution(); r.Version != "v20.11.2" || r.UpgradedFrom != "v20.11.0" || r.SecurityRelease != "v20.12.0" {
+		t.Fatalf("expected an upgrade from v20.11.0 to v20.11.2, got %s from %q (security release %q)", r.Version, r.UpgradedFrom, r.SecurityRelease)
+	}
+}
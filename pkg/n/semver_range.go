@@ -0,0 +1,537 @@
+package n
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	semverv3 "github.com/Masterminds/semver/v3"
+)
+
+// The grammar of node-semver's ranges, as npm reads them for engines. Each
+// piece follows the regular expression of the same name in node-semver's
+// internal/re.js, loose mode left out.
+const (
+	reNumericIdentifier    = `0|[1-9]\d*`
+	reNonNumericIdentifier = `\d*[a-zA-Z-][a-zA-Z0-9-]*`
+
+	reMainVersion = `(` + reNumericIdentifier + `)\.(` + reNumericIdentifier + `)\.(` + reNumericIdentifier + `)`
+
+	rePrereleaseIdentifier = `(?:` + reNumericIdentifier + `|` + reNonNumericIdentifier + `)`
+	rePrerelease           = `(?:-(` + rePrereleaseIdentifier + `(?:\.` + rePrereleaseIdentifier + `)*))`
+
+	reBuildIdentifier = `[a-zA-Z0-9-]+`
+	reBuild           = `(?:\+(` + reBuildIdentifier + `(?:\.` + reBuildIdentifier + `)*))`
+
+	reFullPlain = `v?` + reMainVersion + rePrerelease + `?` + reBuild + `?`
+
+	// only used to trim the space between an operator and its version
+	reLoosePlain = `[v=\s]*(\d+)\.(\d+)\.(\d+)(?:-?((?:\d+|` + reNonNumericIdentifier + `)(?:\.(?:\d+|` + reNonNumericIdentifier + `))*))?` + reBuild + `?`
+
+	reGtlt = `((?:<|>)?=?)`
+
+	reXRangeIdentifier = reNumericIdentifier + `|x|X|\*`
+	reXRangePlain      = `[v=\s]*(` + reXRangeIdentifier + `)(?:\.(` + reXRangeIdentifier + `)(?:\.(` + reXRangeIdentifier + `)(?:` + rePrerelease + `)?` + reBuild + `?)?)?`
+)
+
+var (
+	reComparator     = regexp.MustCompile(`^` + reGtlt + `\s*(` + reFullPlain + `)$|^$`)
+	reComparatorTrim = regexp.MustCompile(`(\s*)` + reGtlt + `\s*(` + reLoosePlain + `|` + reXRangePlain + `)`)
+
+	reHyphenRange = regexp.MustCompile(`^\s*(` + reXRangePlain + `)\s+-\s+(` + reXRangePlain + `)\s*$`)
+
+	reTilde     = regexp.MustCompile(`^(?:~>?)` + reXRangePlain + `$`)
+	reTildeTrim = regexp.MustCompile(`(\s*)(?:~>?)\s+`)
+	reCaret     = regexp.MustCompile(`^(?:\^)` + reXRangePlain + `$`)
+	reCaretTrim = regexp.MustCompile(`(\s*)(?:\^)\s+`)
+	reXRange    = regexp.MustCompile(`^` + reGtlt + `\s*` + reXRangePlain + `$`)
+
+	reStar       = regexp.MustCompile(`(<|>)?=?\s*\*`)
+	reGte0       = regexp.MustCompile(`^\s*>=\s*0\.0\.0\s*$`)
+	reGte0Pre    = regexp.MustCompile(`^\s*>=\s*0\.0\.0-0\s*$`)
+	reBuildPart  = regexp.MustCompile(reBuild)
+	reWhitespace = regexp.MustCompile(`\s+`)
+)
+
+// nullSet is the comparator nothing satisfies
+const nullSet = "<0.0.0-0"
+
+// nodeRange is a version range with the semantics of npm's node-semver, so
+// engines ranges pick the versions npm would accept. A version satisfies it
+// if it satisfies every comparator of any one of its sets.
+type nodeRange struct {
+	raw string
+	set [][]comparator
+
+	// includePrerelease lets prereleases satisfy the range like any other
+	// version, otherwise they only do if a comparator of the same set names a
+	// prerelease of the same major.minor.patch
+	includePrerelease bool
+}
+
+// comparator is an operator and a version, an empty operator is equality and
+// a nil version any version at all
+type comparator struct {
+	op      string
+	version *semverv3.Version
+}
+
+// parseRange parses spec the way node-semver's Range does
+func parseRange(spec string, includePrerelease bool) (*nodeRange, error) {
+	r := &nodeRange{
+		raw:               reWhitespace.ReplaceAllString(strings.TrimSpace(spec), " "),
+		includePrerelease: includePrerelease,
+	}
+
+	for _, part := range strings.Split(r.raw, "||") {
+		comparators, err := r.parseSet(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("invalid range %q: %w", spec, err)
+		}
+
+		r.set = append(r.set, comparators)
+	}
+
+	if len(r.set) > 1 {
+		// drop the sets nothing satisfies, keeping the first if all are
+		first := r.set[0]
+
+		r.set = slices.DeleteFunc(r.set, func(c []comparator) bool { return c[0].value() == nullSet })
+		if len(r.set) == 0 {
+			r.set = [][]comparator{first}
+		}
+
+		// a set anything satisfies makes the rest moot
+		for _, c := range r.set {
+			if len(c) == 1 && c[0].version == nil {
+				r.set = [][]comparator{c}
+				break
+			}
+		}
+	}
+
+	return r, nil
+}
+
+func (r *nodeRange) parseSet(set string) ([]comparator, error) {
+	// 1.2.3 - 1.2.4 => >=1.2.3 <=1.2.4
+	if m := reHyphenRange.FindStringSubmatch(set); m != nil {
+		set = r.hyphen(m)
+	}
+
+	// > 1.2.3 < 1.2.5 => >1.2.3 <1.2.5
+	set = reComparatorTrim.ReplaceAllString(set, "${1}${2}${3}")
+	// ~ 1.2.3 => ~1.2.3
+	set = reTildeTrim.ReplaceAllString(set, "${1}~")
+	// ^ 1.2.3 => ^1.2.3
+	set = reCaretTrim.ReplaceAllString(set, "${1}^")
+
+	var parsed []string
+	for _, comp := range strings.Split(set, " ") {
+		parsed = append(parsed, r.desugar(comp))
+	}
+
+	var comparators []comparator
+
+	seen := map[string]bool{}
+
+	for _, comp := range reWhitespace.Split(strings.Join(parsed, " "), -1) {
+		// >=0.0.0 is any version
+		if r.includePrerelease {
+			comp = reGte0Pre.ReplaceAllString(comp, "")
+		} else {
+			comp = reGte0.ReplaceAllString(comp, "")
+		}
+
+		c, err := parseComparator(comp)
+		if err != nil {
+			return nil, err
+		}
+
+		if c.value() == nullSet {
+			return []comparator{c}, nil
+		}
+
+		if seen[c.value()] {
+			continue
+		}
+
+		seen[c.value()] = true
+
+		comparators = append(comparators, c)
+	}
+
+	// any version is implied as soon as there is another comparator
+	if len(comparators) > 1 {
+		comparators = slices.DeleteFunc(comparators, func(c comparator) bool { return c.version == nil })
+	}
+
+	return comparators, nil
+}
+
+// desugar turns carets, tildes, x-ranges and stars into plain comparators
+func (r *nodeRange) desugar(comp string) string {
+	comp = replaceFirst(reBuildPart, comp, "")
+
+	var parts []string
+
+	for _, c := range reWhitespace.Split(strings.TrimSpace(comp), -1) {
+		parts = append(parts, r.caret(c))
+	}
+
+	comp = strings.Join(parts, " ")
+	parts = parts[:0]
+
+	for _, c := range reWhitespace.Split(strings.TrimSpace(comp), -1) {
+		parts = append(parts, tilde(c))
+	}
+
+	comp = strings.Join(parts, " ")
+	parts = parts[:0]
+
+	for _, c := range reWhitespace.Split(comp, -1) {
+		parts = append(parts, r.xrange(c))
+	}
+
+	comp = strings.Join(parts, " ")
+
+	// * is and-ed with the rest, on its own it's the empty comparator anyway
+	return replaceFirst(reStar, strings.TrimSpace(comp), "")
+}
+
+// ~1.2.3 => >=1.2.3 <1.3.0-0, ~1.2 => >=1.2.0 <1.3.0-0, ~1 => >=1.0.0 <2.0.0-0
+func tilde(comp string) string {
+	m := reTilde.FindStringSubmatch(comp)
+	if m == nil {
+		return comp
+	}
+
+	major, minor, patch, pre := m[1], m[2], m[3], m[4]
+
+	switch {
+	case isX(major):
+		return ""
+	case isX(minor):
+		return fmt.Sprintf(">=%s.0.0 <%d.0.0-0", major, inc(major))
+	case isX(patch):
+		return fmt.Sprintf(">=%s.%s.0 <%s.%d.0-0", major, minor, major, inc(minor))
+	case pre != "":
+		return fmt.Sprintf(">=%s.%s.%s-%s <%s.%d.0-0", major, minor, patch, pre, major, inc(minor))
+	}
+
+	return fmt.Sprintf(">=%s.%s.%s <%s.%d.0-0", major, minor, patch, major, inc(minor))
+}
+
+// ^1.2.3 => >=1.2.3 <2.0.0-0, ^0.2.3 => >=0.2.3 <0.3.0-0, ^0.0.3 => >=0.0.3 <0.0.4-0
+func (r *nodeRange) caret(comp string) string {
+	m := reCaret.FindStringSubmatch(comp)
+	if m == nil {
+		return comp
+	}
+
+	major, minor, patch, pre := m[1], m[2], m[3], m[4]
+
+	z := ""
+	if r.includePrerelease {
+		z = "-0"
+	}
+
+	switch {
+	case isX(major):
+		return ""
+	case isX(minor):
+		return fmt.Sprintf(">=%s.0.0%s <%d.0.0-0", major, z, inc(major))
+	case isX(patch):
+		if major == "0" {
+			return fmt.Sprintf(">=%s.%s.0%s <%s.%d.0-0", major, minor, z, major, inc(minor))
+		}
+
+		return fmt.Sprintf(">=%s.%s.0%s <%d.0.0-0", major, minor, z, inc(major))
+	}
+
+	from := major + "." + minor + "." + patch
+	if pre != "" {
+		from += "-" + pre
+	} else if major == "0" {
+		from += z
+	}
+
+	switch {
+	case major == "0" && minor == "0":
+		return fmt.Sprintf(">=%s <%s.%s.%d-0", from, major, minor, inc(patch))
+	case major == "0":
+		return fmt.Sprintf(">=%s <%s.%d.0-0", from, major, inc(minor))
+	}
+
+	return fmt.Sprintf(">=%s <%d.0.0-0", from, inc(major))
+}
+
+// 1.2.x => >=1.2.0 <1.3.0-0, >1 => >=2.0.0, <=1.2 => <1.3.0-0
+func (r *nodeRange) xrange(comp string) string {
+	comp = strings.TrimSpace(comp)
+
+	m := reXRange.FindStringSubmatch(comp)
+	if m == nil {
+		return comp
+	}
+
+	op, major, minor, patch := m[1], m[2], m[3], m[4]
+
+	xMajor := isX(major)
+	xMinor := xMajor || isX(minor)
+	xPatch := xMinor || isX(patch)
+
+	if op == "=" && xPatch {
+		op = ""
+	}
+
+	pre := ""
+	if r.includePrerelease {
+		pre = "-0"
+	}
+
+	switch {
+	case xMajor:
+		if op == ">" || op == "<" {
+			return nullSet
+		}
+
+		return "*"
+	case op != "" && xPatch:
+		M, m, p := mustUint(major), uint64(0), uint64(0)
+		if !xMinor {
+			m = mustUint(minor)
+		}
+
+		switch op {
+		case ">":
+			// >1 => >=2.0.0, >1.2 => >=1.3.0
+			op = ">="
+			if xMinor {
+				M, m = M+1, 0
+			} else {
+				m++
+			}
+		case "<=":
+			// <=0.7.x is <0.8.0-0, any 0.7.x satisfies it
+			op = "<"
+			if xMinor {
+				M++
+			} else {
+				m++
+			}
+		}
+
+		if op == "<" {
+			pre = "-0"
+		}
+
+		return fmt.Sprintf("%s%d.%d.%d%s", op, M, m, p, pre)
+	case xMinor:
+		return fmt.Sprintf(">=%s.0.0%s <%d.0.0-0", major, pre, inc(major))
+	case xPatch:
+		return fmt.Sprintf(">=%s.%s.0%s <%s.%d.0-0", major, minor, pre, major, inc(minor))
+	}
+
+	return comp
+}
+
+// 1.2 - 3.4.5 => >=1.2.0 <=3.4.5, 1.2.3 - 3.4 => >=1.2.3 <3.5.0-0
+func (r *nodeRange) hyphen(m []string) string {
+	from, fMajor, fMinor, fPatch, fPre := m[1], m[2], m[3], m[4], m[5]
+	to, tMajor, tMinor, tPatch, tPre := m[7], m[8], m[9], m[10], m[11]
+
+	z := ""
+	if r.includePrerelease {
+		z = "-0"
+	}
+
+	switch {
+	case isX(fMajor):
+		from = ""
+	case isX(fMinor):
+		from = ">=" + fMajor + ".0.0" + z
+	case isX(fPatch):
+		from = ">=" + fMajor + "." + fMinor + ".0" + z
+	case fPre != "":
+		from = ">=" + from
+	default:
+		from = ">=" + from + z
+	}
+
+	switch {
+	case isX(tMajor):
+		to = ""
+	case isX(tMinor):
+		to = fmt.Sprintf("<%d.0.0-0", inc(tMajor))
+	case isX(tPatch):
+		to = fmt.Sprintf("<%s.%d.0-0", tMajor, inc(tMinor))
+	case tPre != "":
+		to = "<=" + tMajor + "." + tMinor + "." + tPatch + "-" + tPre
+	case r.includePrerelease:
+		to = fmt.Sprintf("<%s.%s.%d-0", tMajor, tMinor, inc(tPatch))
+	default:
+		to = "<=" + to
+	}
+
+	return strings.TrimSpace(from + " " + to)
+}
+
+func parseComparator(comp string) (comparator, error) {
+	m := reComparator.FindStringSubmatch(comp)
+	if m == nil {
+		return comparator{}, fmt.Errorf("invalid comparator %q", comp)
+	}
+
+	if m[2] == "" {
+		return comparator{}, nil
+	}
+
+	v, err := semverv3.StrictNewVersion(strings.TrimPrefix(m[2], "v"))
+	if err != nil {
+		return comparator{}, fmt.Errorf("invalid comparator %q: %w", comp, err)
+	}
+
+	op := m[1]
+	if op == "=" {
+		op = ""
+	}
+
+	return comparator{op: op, version: v}, nil
+}
+
+func (c comparator) value() string {
+	if c.version == nil {
+		return ""
+	}
+
+	// build metadata doesn't count, 1.2.3+a is 1.2.3
+	v, _, _ := strings.Cut(c.version.String(), "+")
+
+	return c.op + v
+}
+
+func (c comparator) test(v *semverv3.Version) bool {
+	if c.version == nil {
+		return true
+	}
+
+	d := v.Compare(c.version)
+
+	switch c.op {
+	case "<":
+		return d < 0
+	case "<=":
+		return d <= 0
+	case ">":
+		return d > 0
+	case ">=":
+		return d >= 0
+	}
+
+	return d == 0
+}
+
+// Contains reports whether v satisfies r
+func (r *nodeRange) Contains(v *semverv3.Version) bool {
+	for _, set := range r.set {
+		if r.testSet(set, v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *nodeRange) testSet(set []comparator, v *semverv3.Version) bool {
+	for _, c := range set {
+		if !c.test(v) {
+			return false
+		}
+	}
+
+	if v.Prerelease() == "" || r.includePrerelease {
+		return true
+	}
+
+	// a prerelease only satisfies a set that opts into prereleases of its
+	// major.minor.patch, 1.2.3-rc.2 satisfies >=1.2.3-rc.1 but not >=1.2.0
+	for _, c := range set {
+		if c.version == nil || c.version.Prerelease() == "" {
+			continue
+		}
+
+		if c.version.Major() == v.Major() && c.version.Minor() == v.Minor() && c.version.Patch() == v.Patch() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Compare returns 2 if v satisfies r and 3 if it doesn't, like
+// semverv3Constraints
+func (r *nodeRange) Compare(v *semverv3.Version) int {
+	if r.Contains(v) {
+		return 2
+	}
+
+	return 3
+}
+
+func (r *nodeRange) String() string {
+	return r.raw
+}
+
+// below reports whether some version lower than v could satisfy r, going by
+// the lower bounds of its sets
+func (r *nodeRange) below(v *semverv3.Version) bool {
+	for _, set := range r.set {
+		if len(set) == 1 && set[0].value() == nullSet {
+			continue
+		}
+
+		var lowest *semverv3.Version
+
+		for _, c := range set {
+			if c.version == nil || strings.HasPrefix(c.op, "<") {
+				continue
+			}
+
+			if lowest == nil || c.version.GreaterThan(lowest) {
+				lowest = c.version
+			}
+		}
+
+		if lowest == nil || lowest.LessThan(v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isX(id string) bool {
+	return id == "" || id == "x" || id == "X" || id == "*"
+}
+
+func inc(id string) uint64 {
+	return mustUint(id) + 1
+}
+
+// mustUint parses identifiers the grammar already matched as numbers
+func mustUint(id string) uint64 {
+	n, _ := strconv.ParseUint(id, 10, 64)
+	return n
+}
+
+func replaceFirst(re *regexp.Regexp, s, repl string) string {
+	loc := re.FindStringIndex(s)
+	if loc == nil {
+		return s
+	}
+
+	return s[:loc[0]] + repl + s[loc[1]:]
+}
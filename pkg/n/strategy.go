@@ -0,0 +1,99 @@
+package n
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// Strategy decides which of the releases satisfying a version constraint
+// NewNodeManager picks
+type Strategy string
+
+const (
+	// StrategyNewest picks the newest satisfying release, the default
+	StrategyNewest Strategy = "newest"
+	// StrategyPreferInstalled picks the newest satisfying release that is
+	// already installed, falling back to the newest one. A new patch release
+	// isn't downloaded while an installed version still satisfies the range.
+	StrategyPreferInstalled Strategy = "prefer-installed"
+	// StrategyOldestSatisfying picks the oldest satisfying release
+	StrategyOldestSatisfying Strategy = "oldest-satisfying"
+	// StrategyLtsPreferred picks the newest satisfying lts release, falling
+	// back to the newest one
+	StrategyLtsPreferred Strategy = "lts-preferred"
+)
+
+// Strategies are the known strategies
+var Strategies = []Strategy{StrategyNewest, StrategyPreferInstalled, StrategyOldestSatisfying, StrategyLtsPreferred}
+
+// WithStrategy picks releases satisfying a version constraint with s. It
+// only applies to constraints, latest and lts specs always resolve to the
+// newest release. An empty s keeps StrategyNewest.
+func WithStrategy(s Strategy) Option {
+	return func(n *N) {
+		if s != "" {
+			n.strategy = s
+		}
+	}
+}
+
+func (s Strategy) valid() bool {
+	for _, known := range Strategies {
+		if s == known {
+			return true
+		}
+	}
+
+	return false
+}
+
+// order returns releases, newest first, in the order the strategy wants them
+// tried
+func (n *N) order(releases []nCacheItem) ([]nCacheItem, error) {
+	switch n.strategy {
+	case StrategyNewest:
+		return releases, nil
+	case StrategyOldestSatisfying:
+		ordered := make([]nCacheItem, len(releases))
+		for i, release := range releases {
+			ordered[len(releases)-1-i] = release
+		}
+
+		return ordered, nil
+	case StrategyPreferInstalled:
+		return partition(releases, n.isInstalled), nil
+	case StrategyLtsPreferred:
+		return partition(releases, func(release nCacheItem) bool {
+			lts, _ := release.Lts.(string)
+			return lts != ""
+		}), nil
+	}
+
+	return nil, fmt.Errorf("unknown resolution strategy %q", n.strategy)
+}
+
+// isInstalled reports whether release is installed for this platform
+func (n *N) isInstalled(release nCacheItem) bool {
+	_, err := os.Stat(filepath.Join(n.versionsDir(), release.Version, runtime.GOOS, n.arch, "bin", "node"))
+	return err == nil
+}
+
+// partition moves the releases first is true for to the front, keeping the
+// order within both groups
+func partition(releases []nCacheItem, first func(nCacheItem) bool) []nCacheItem {
+	ordered := make([]nCacheItem, 0, len(releases))
+
+	var rest []nCacheItem
+
+	for _, release := range releases {
+		if first(release) {
+			ordered = append(ordered, release)
+		} else {
+			rest = append(rest, release)
+		}
+	}
+
+	return append(ordered, rest...)
+}
@@ -0,0 +1,43 @@
+package n
+
+import "testing"
+
+func TestStrategy(t *testing.T) {
+	root := t.TempDir()
+	installFake(t, root, "v20.11.0")
+
+	writeCache(t, root)
+
+	cases := []struct {
+		strategy Strategy
+		spec     string
+		expected string
+	}{
+		{"", "^20", "v20.18.0"},
+		{StrategyNewest, "^20", "v20.18.0"},
+		{StrategyPreferInstalled, "^20", "v20.11.0"},
+		{StrategyPreferInstalled, "^22", "v22.11.0"},
+		{StrategyOldestSatisfying, "^20", "v20.11.0"},
+		{StrategyOldestSatisfying, ">=18", "v18.17.0"},
+		{StrategyLtsPreferred, ">=22", "v22.11.0"},
+		{StrategyLtsPreferred, "^23", "v23.1.0"},
+		// only constraints are affected
+		{StrategyOldestSatisfying, "iron", "v20.18.0"},
+		{StrategyPreferInstalled, "latest", "v23.1.0"},
+	}
+
+	for _, c := range cases {
+		n, err := NewNodeManager(false, c.spec, root, WithMirror("http://127.0.0.1:1"), WithStrategy(c.strategy))
+		if err != nil {
+			t.Fatalf("%s %s: %v", c.strategy, c.spec, err)
+		}
+
+		if got := n.Resolution().Version; got != c.expected {
+			t.Fatalf("%s %s: expected %s, got %s", c.strategy, c.spec, c.expected, got)
+		}
+	}
+
+	if _, err := NewNodeManager(false, "^20", root, WithStrategy("fastest")); err == nil {
+		t.Fatal("expected an unknown strategy to fail")
+	}
+}
@@ -0,0 +1,91 @@
+package n
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// DefaultUnofficialMirror is where builds the official index doesn't have,
+// e.g. linux-x64-musl or linux-riscv64, are looked for
+const DefaultUnofficialMirror = "https://unofficial-builds.nodejs.org/download/release"
+
+// WithUnofficialBuilds looks for builds missing from the official index in
+// mirrors, tried in turn like WithMirrors. Without mirrors only official
+// builds are used.
+func WithUnofficialBuilds(mirrors ...string) Option {
+	return func(n *N) {
+		n.unofficialMirrors = nil
+		for _, mirror := range mirrors {
+			if mirror != "" {
+				n.unofficialMirrors = append(n.unofficialMirrors, trimMirror(mirror))
+			}
+		}
+	}
+}
+
+// isMusl reports whether the system's libc is musl, e.g. on alpine, whose
+// ldd is a script running the musl loader. A glibc system with musl installed
+// alongside still has glibc's ldd.
+var isMusl = func() bool {
+	if ldd, err := os.ReadFile("/usr/bin/ldd"); err == nil {
+		return bytes.Contains(ldd, []byte("musl"))
+	}
+
+	loaders, _ := filepath.Glob("/lib/ld-musl-*.so.1")
+
+	return len(loaders) > 0
+}
+
+// build reports whether release has a build for archiveType, and whether
+// that is an unofficial one
+func (n *N) build(release nCacheItem, archiveType string) (found, unofficial bool) {
+	for _, file := range release.Files {
+		if file == archiveType {
+			return true, false
+		}
+	}
+
+	for _, u := range n.unofficialIndex() {
+		if u.Version != release.Version {
+			continue
+		}
+
+		for _, file := range u.Files {
+			if file == archiveType {
+				return true, true
+			}
+		}
+
+		break
+	}
+
+	return false, false
+}
+
+// unofficialFile is where the unofficial builds index is cached, under the
+// root dir
+const unofficialFile = "node_versions_unofficial.json"
+
+func (n *N) fetchUnofficial(prev validators) ([]byte, validators, error) {
+	return fetchIndex(n.unofficialMirrors, prev)
+}
+
+// unofficialIndex returns the unofficial builds index, cached for a day like
+// the official one. It's only fetched once an official release turns out to
+// lack a build, if it can't be, there are no unofficial builds.
+func (n *N) unofficialIndex() nCache {
+	if n.unofficialCache != nil || n.offline || len(n.unofficialMirrors) == 0 {
+		return n.unofficialCache
+	}
+
+	// don't try again for every release
+	n.unofficialCache = nCache{}
+
+	content, _ := n.cached(filepath.Join(n.rootDir, unofficialFile), n.fetchUnofficial)
+
+	json.Unmarshal(content, &n.unofficialCache)
+
+	return n.unofficialCache
+}
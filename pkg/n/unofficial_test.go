@@ -0,0 +1,81 @@
+package n
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func serveIndex(t *testing.T, index nCache) *httptest.Server {
+	t.Helper()
+
+	b, err := json.Marshal(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/index.json" {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Write(b)
+	}))
+
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func TestUnofficialBuilds(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("musl builds are linux only")
+	}
+
+	defer func(detect func() bool) { isMusl = detect }(isMusl)
+	isMusl = func() bool { return true }
+
+	arch := nodeArches[runtime.GOARCH] + "-musl"
+
+	official := serveIndex(t, nCache{
+		{Version: "v20.12.0", Files: []string{"linux-x64", "linux-arm64"}},
+		{Version: "v20.11.0", Files: []string{"linux-x64", "linux-arm64"}},
+	})
+
+	// unofficial builds lag behind, v20.12.0 isn't there yet
+	unofficial := serveIndex(t, nCache{
+		{Version: "v20.11.0", Files: []string{"linux-" + arch}},
+	})
+
+	n, err := NewNodeManager(false, "^20", t.TempDir(), WithMirror(official.URL), WithUnofficialBuilds(unofficial.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := n.Resolution()
+	if r.Version != "v20.11.0" || !r.Unofficial || r.ArchiveType != "linux-"+arch {
+		t.Fatalf("expected the unofficial v20.11.0 %s build, got %+v", arch, r)
+	}
+
+	if len(r.Skipped) != 1 || r.Skipped[0].Version != "v20.12.0" {
+		t.Fatalf("expected v20.12.0 to be skipped, got %+v", r.Skipped)
+	}
+
+	urls, _ := n._assets()
+	if len(urls) != 1 || !strings.HasPrefix(urls[0], unofficial.URL+"/v20.11.0/node-v20.11.0-linux-"+arch) {
+		t.Fatalf("expected to download from the unofficial mirror, got %v", urls)
+	}
+
+	if !strings.HasSuffix(n.InstallDir(), "/linux/"+arch) {
+		t.Fatalf("expected a separate install dir for %s, got %s", arch, n.InstallDir())
+	}
+
+	// turned off, there is nothing to install
+	if _, err := NewNodeManager(false, "^20", t.TempDir(), WithMirror(official.URL), WithUnofficialBuilds()); err == nil {
+		t.Fatal("expected no build to be found without unofficial builds")
+	}
+}
@@ -0,0 +1,263 @@
+package sources
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// dockerfileNames are read in this order, the first one with a node image wins
+var dockerfileNames = []string{"Dockerfile", "Containerfile"}
+
+func sourceDockerfile(dir string) (string, string, error) {
+	candidates := make([]string, 0, len(dockerfileNames))
+	for _, name := range dockerfileNames {
+		candidates = append(candidates, filepath.Join(dir, name))
+	}
+
+	named, err := filepath.Glob(filepath.Join(dir, "*.Dockerfile"))
+	if err != nil {
+		return "", "", err
+	}
+
+	sort.Strings(named)
+
+	candidates = append(candidates, named...)
+
+	for _, path := range candidates {
+		f, err := os.Open(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+
+			return "", path, fmt.Errorf("failed to open %s: %w", path, err)
+		}
+
+		version, err := parseDockerfile(f)
+		f.Close()
+		if err != nil {
+			return "", path, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+
+		if version != "" {
+			return version, path, nil
+		}
+	}
+
+	return "", filepath.Join(dir, dockerfileNames[0]), nil
+}
+
+type dockerStage struct {
+	image string
+	name  string
+}
+
+// parseDockerfile returns the nodejs version of the image the Dockerfile
+// builds. The final stage is followed through named stages down to its base
+// image, if that isn't node (e.g. static files copied into nginx), the last
+// stage that is built on node is used instead.
+func parseDockerfile(r io.Reader) (string, error) {
+	instructions, err := dockerfileInstructions(r)
+	if err != nil {
+		return "", err
+	}
+
+	var (
+		args   = map[string]string{}
+		stages []dockerStage
+	)
+
+	for _, instruction := range instructions {
+		keyword, rest, _ := strings.Cut(instruction, " ")
+		fields := strings.Fields(rest)
+
+		switch strings.ToUpper(keyword) {
+		case "ARG":
+			// only args declared before the first FROM can be used in FROM
+			if len(stages) > 0 {
+				continue
+			}
+
+			for _, field := range fields {
+				name, value, _ := strings.Cut(field, "=")
+				args[name] = strings.Trim(value, `"'`)
+			}
+		case "FROM":
+			var stage dockerStage
+
+			for i := 0; i < len(fields); i++ {
+				switch {
+				case strings.HasPrefix(fields[i], "--"):
+					continue
+				case strings.EqualFold(fields[i], "as") && i+1 < len(fields):
+					stage.name = strings.ToLower(fields[i+1])
+					i++
+				case stage.image == "":
+					stage.image = expandDockerArgs(fields[i], args)
+				}
+			}
+
+			if stage.image == "" {
+				continue
+			}
+
+			stages = append(stages, stage)
+		}
+	}
+
+	if len(stages) == 0 {
+		return "", nil
+	}
+
+	byName := map[string]int{}
+
+	// base follows stage i through the stages it is built FROM
+	base := func(i int) string {
+		image := stages[i].image
+
+		for hops := 0; hops < len(stages); hops++ {
+			j, ok := byName[strings.ToLower(image)]
+			if !ok || j >= i {
+				break
+			}
+
+			image, i = stages[j].image, j
+		}
+
+		return image
+	}
+
+	bases := make([]string, len(stages))
+	for i, stage := range stages {
+		bases[i] = base(i)
+
+		if stage.name != "" {
+			byName[stage.name] = i
+		}
+	}
+
+	for i := len(bases) - 1; i >= 0; i-- {
+		if version, ok := nodeImageVersion(bases[i]); ok {
+			return version, nil
+		}
+	}
+
+	return "", nil
+}
+
+// dockerfileInstructions returns the instructions of a Dockerfile, with line
+// continuations joined and comments dropped
+func dockerfileInstructions(r io.Reader) ([]string, error) {
+	var (
+		instructions []string
+		current      strings.Builder
+	)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if continued, ok := strings.CutSuffix(line, "\\"); ok {
+			current.WriteString(continued)
+			current.WriteString(" ")
+			continue
+		}
+
+		current.WriteString(line)
+
+		if instruction := strings.Join(strings.Fields(current.String()), " "); instruction != "" {
+			instructions = append(instructions, instruction)
+		}
+
+		current.Reset()
+	}
+
+	if instruction := strings.Join(strings.Fields(current.String()), " "); instruction != "" {
+		instructions = append(instructions, instruction)
+	}
+
+	return instructions, scanner.Err()
+}
+
+// expandDockerArgs expands $VAR, ${VAR}, ${VAR:-default} and ${VAR:+value}
+func expandDockerArgs(s string, args map[string]string) string {
+	return os.Expand(s, func(name string) string {
+		if name, word, ok := strings.Cut(name, ":-"); ok {
+			if args[name] == "" {
+				return word
+			}
+
+			return args[name]
+		}
+
+		if name, word, ok := strings.Cut(name, ":+"); ok {
+			if args[name] == "" {
+				return ""
+			}
+
+			return word
+		}
+
+		return args[name]
+	})
+}
+
+// dockerTagVariants are tags that only pick a base distribution, not a version
+var dockerTagVariants = []string{"alpine", "slim", "bookworm", "bullseye", "buster", "stretch", "jessie", "trixie", "windowsservercore", "nanoserver"}
+
+// nodeImageVersion returns the version the official node image ref runs, if
+// ref is one, e.g. node:20-alpine, docker.io/library/node:lts-slim. The
+// version is empty when the tag has none, e.g. node:-alpine from an ARG
+// without a default that only --build-arg sets.
+func nodeImageVersion(ref string) (string, bool) {
+	ref = strings.ToLower(ref)
+	ref, _, _ = strings.Cut(ref, "@") // digest
+
+	repository, tag, tagged := ref, "", false
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		repository, tag, tagged = ref[:i], ref[i+1:], true
+	}
+
+	for _, registry := range []string{"docker.io/", "index.docker.io/", "registry-1.docker.io/"} {
+		repository = strings.TrimPrefix(repository, registry)
+	}
+
+	if strings.TrimPrefix(repository, "library/") != "node" {
+		return "", false
+	}
+
+	version, _, _ := strings.Cut(tag, "-")
+
+	switch {
+	case !tagged || version == "latest" || version == "current":
+		return "latest", true
+	case version == "":
+		return "", true
+	case version == "lts":
+		return "lts", true
+	case strings.Trim(version, "0123456789.") == "":
+		if isPartialVersion(version) {
+			return version + ".x", true
+		}
+
+		return version, true
+	}
+
+	for _, variant := range dockerTagVariants {
+		if strings.HasPrefix(version, variant) {
+			return "latest", true
+		}
+	}
+
+	// anything else should be an lts codename, e.g. iron-slim
+	return version, true
+}
@@ -0,0 +1,250 @@
+package sources
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/semver"
+	"gopkg.in/yaml.v3"
+)
+
+type workflow struct {
+	Env  map[string]string `yaml:"env"`
+	Jobs yaml.Node         `yaml:"jobs"` // a node to keep the jobs in order
+}
+
+type workflowJob struct {
+	Env map[string]string `yaml:"env"`
+
+	Strategy struct {
+		Matrix yaml.Node `yaml:"matrix"` // can also be an expression
+	} `yaml:"strategy"`
+
+	Steps []struct {
+		Uses string            `yaml:"uses"`
+		With map[string]string `yaml:"with"`
+	} `yaml:"steps"`
+}
+
+// sourceGithubWorkflows reads the version actions/setup-node is asked to set
+// up in .github/workflows, the first workflow (by name) that has one wins
+func sourceGithubWorkflows(dir string) (string, string, error) {
+	workflows := filepath.Join(dir, ".github", "workflows")
+
+	var paths []string
+	for _, pattern := range []string{"*.yml", "*.yaml"} {
+		matches, err := filepath.Glob(filepath.Join(workflows, pattern))
+		if err != nil {
+			return "", workflows, err
+		}
+
+		paths = append(paths, matches...)
+	}
+
+	sort.Strings(paths)
+
+	var firstErr error
+
+	for _, path := range paths {
+		version, err := workflowNodeVersion(dir, path)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to read %s: %w", path, err)
+			}
+
+			continue
+		}
+
+		if version != "" {
+			return version, path, nil
+		}
+	}
+
+	return "", workflows, firstErr
+}
+
+// workflowNodeVersion returns the version of the first setup-node step of
+// the workflow at path, repository being where it runs from
+func workflowNodeVersion(repository, path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	var w workflow
+	if err := yaml.Unmarshal(b, &w); err != nil {
+		return "", err
+	}
+
+	for i := 1; i < len(w.Jobs.Content); i += 2 {
+		var job workflowJob
+		if err := w.Jobs.Content[i].Decode(&job); err != nil {
+			return "", fmt.Errorf("failed to read job %s: %w", w.Jobs.Content[i-1].Value, err)
+		}
+
+		for _, step := range job.Steps {
+			if !strings.HasPrefix(step.Uses, "actions/setup-node@") {
+				continue
+			}
+
+			if version := step.With["node-version"]; version != "" {
+				version = job.expand(lastLine(version), w.Env)
+				if version == "" {
+					continue
+				}
+
+				if version == "current" {
+					return "latest", nil
+				}
+
+				return parseNvmrc(version)
+			}
+
+			if file := step.With["node-version-file"]; file != "" {
+				return versionFromFile(filepath.Join(repository, file))
+			}
+		}
+	}
+
+	return "", nil
+}
+
+// lastLine returns the last line of a multi line node-version, setup-node
+// installs all of them but the last one is the one left on PATH
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+var workflowExpression = regexp.MustCompile(`^\$\{\{\s*(matrix|env)\.([\w-]+)\s*\}\}$`)
+
+// expand resolves matrix and env expressions, anything else it can't know
+// the value of comes back empty
+func (job *workflowJob) expand(value string, env map[string]string) string {
+	if !strings.Contains(value, "${{") {
+		return value
+	}
+
+	m := workflowExpression.FindStringSubmatch(value)
+	if m == nil {
+		return ""
+	}
+
+	if m[1] == "env" {
+		if v, ok := job.Env[m[2]]; ok {
+			return v
+		}
+
+		return env[m[2]]
+	}
+
+	return matrixVersion(&job.Strategy.Matrix, m[2])
+}
+
+// matrixVersion picks the version out of matrix key: the include entry
+// marked with primary: true if there is one, else the highest version
+func matrixVersion(matrix *yaml.Node, key string) string {
+	if matrix.Kind != yaml.MappingNode {
+		return ""
+	}
+
+	var candidates []string
+
+	for i := 1; i < len(matrix.Content); i += 2 {
+		name, value := matrix.Content[i-1].Value, matrix.Content[i]
+
+		switch {
+		case name == key && value.Kind == yaml.ScalarNode:
+			candidates = append(candidates, value.Value)
+		case name == key && value.Kind == yaml.SequenceNode:
+			for _, item := range value.Content {
+				if item.Kind == yaml.ScalarNode {
+					candidates = append(candidates, item.Value)
+				}
+			}
+		case name == "include" && value.Kind == yaml.SequenceNode:
+			for _, item := range value.Content {
+				var include map[string]string
+				if err := item.Decode(&include); err != nil || include[key] == "" {
+					continue
+				}
+
+				if primary, _ := strconv.ParseBool(include["primary"]); primary {
+					return include[key]
+				}
+
+				candidates = append(candidates, include[key])
+			}
+		}
+	}
+
+	return highestVersion(candidates)
+}
+
+// highestVersion compares loosely, 20.x is higher than 18.20.4. Anything
+// that isn't a version (lts/*) is lower than all versions.
+func highestVersion(versions []string) string {
+	loose := func(v string) string {
+		v = strings.TrimPrefix(strings.ToLower(v), "v")
+		for strings.HasSuffix(v, ".x") || strings.HasSuffix(v, ".*") {
+			v = v[:len(v)-2]
+		}
+
+		return "v" + v
+	}
+
+	highest := ""
+
+	for _, v := range versions {
+		switch {
+		case highest == "":
+			highest = v
+		case !semver.IsValid(loose(v)):
+			continue
+		case !semver.IsValid(loose(highest)) || semver.Compare(loose(v), loose(highest)) > 0:
+			highest = v
+		}
+	}
+
+	return highest
+}
+
+// versionFromFile reads a version out of a file the way setup-node's
+// node-version-file does
+func versionFromFile(path string) (string, error) {
+	switch filepath.Base(path) {
+	case "package.json":
+		p, err := readPackageJson(path)
+		if err != nil {
+			return "", err
+		}
+
+		return p.nodeVersion(path)
+	case ".tool-versions":
+		f, err := os.Open(path)
+		if err != nil {
+			return "", err
+		}
+
+		defer f.Close()
+
+		versions, err := parseToolVersions(f)
+		if err != nil || len(versions) == 0 {
+			return "", err
+		}
+
+		return versions[0], nil
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	return parseNvmrc(string(b))
+}
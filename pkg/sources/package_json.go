@@ -0,0 +1,164 @@
+package sources
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type packageJson struct {
+	DevEngines struct {
+		Runtime        devEngineList `json:"runtime"`
+		PackageManager devEngineList `json:"packageManager"`
+	} `json:"devEngines"`
+
+	Engines struct {
+		Node string `json:"node"`
+	} `json:"engines"`
+
+	Volta struct {
+		Node string `json:"node"`
+	} `json:"volta"`
+
+	PackageManager string `json:"packageManager"`
+
+	Workspaces json.RawMessage `json:"workspaces"`
+}
+
+// devEngine is an entry of devEngines, https://docs.npmjs.com/cli/configuring-npm/package-json#devengines
+type devEngine struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	OnFail  string `json:"onFail"`
+}
+
+// devEngineList is either a single devEngine or a list of alternatives
+type devEngineList []devEngine
+
+func (l *devEngineList) UnmarshalJSON(b []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("[")) {
+		return json.Unmarshal(b, (*[]devEngine)(l))
+	}
+
+	var e devEngine
+	if err := json.Unmarshal(b, &e); err != nil {
+		return err
+	}
+
+	*l = devEngineList{e}
+
+	return nil
+}
+
+// find returns the first entry for name that isn't to be ignored
+func (l devEngineList) find(name string) *devEngine {
+	for i := range l {
+		if l[i].Name == name && l[i].OnFail != "ignore" {
+			return &l[i]
+		}
+	}
+
+	return nil
+}
+
+func readPackageJson(path string) (*packageJson, error) {
+	var p packageJson
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	if err := json.NewDecoder(f).Decode(&p); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+func sourcePackageJson(dir string) (string, string, error) {
+	path := filepath.Join(dir, "package.json")
+
+	p, err := readPackageJson(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", path, nil
+		}
+
+		return "", path, fmt.Errorf("failed to read package.json: %w", err)
+	}
+
+	version, err := p.nodeVersion(path)
+
+	return version, path, err
+}
+
+// nodeVersion returns the version from devEngines, engines or volta, in that order
+func (p *packageJson) nodeVersion(path string) (string, error) {
+	if runtime := p.DevEngines.Runtime.find("node"); runtime != nil {
+		if runtime.Version != "" {
+			return runtime.Version, nil
+		}
+
+		if runtime.OnFail != OnFailWarn {
+			return "", fmt.Errorf("devEngines.runtime for node has no version")
+		}
+
+		log.Printf("devEngines.runtime for node in %s has no version, ignoring it", path)
+	}
+
+	if p.Engines.Node != "" {
+		return p.Engines.Node, nil
+	}
+
+	return p.Volta.Node, nil
+}
+
+// packageJsonSource honors devEngines' onFail
+type packageJsonSource struct {
+	Source
+}
+
+func (s packageJsonSource) OnFail(path string) OnFail {
+	p, err := readPackageJson(path)
+	if err != nil {
+		return OnFailError
+	}
+
+	if runtime := p.DevEngines.Runtime.find("node"); runtime != nil && runtime.Version != "" && runtime.OnFail == OnFailWarn {
+		return OnFailWarn
+	}
+
+	return OnFailError
+}
+
+// DetectPackageManager walks up from dir like Detect does, looking for
+// a package.json declaring a package manager, either through packageManager
+// ("pnpm@9.1.0+sha512...") or devEngines.packageManager. The integrity hash
+// of packageManager, if any, is dropped.
+func DetectPackageManager(dir string, depth int) (name string, version string, found bool) {
+	for _, dir := range Dirs(dir, depth) {
+		p, err := readPackageJson(filepath.Join(dir, "package.json"))
+		if err == nil {
+			if p.PackageManager != "" {
+				name, version, _ = strings.Cut(p.PackageManager, "@")
+				version, _, _ = strings.Cut(version, "+")
+				return name, version, true
+			}
+
+			for _, pm := range p.DevEngines.PackageManager {
+				if pm.Name != "" && pm.OnFail != "ignore" {
+					return pm.Name, pm.Version, true
+				}
+			}
+		}
+	}
+
+	return "", "", false
+}
@@ -0,0 +1,294 @@
+package sources
+
+import (
+	"log"
+	"sort"
+)
+
+// DetectFunc looks for a nodejs version in dir. It returns the raw version
+// found, if any, and the path (or variable) it was read from.
+type DetectFunc func(dir string) (version string, path string, err error)
+
+// Source is a place a nodejs version can be read from, e.g. .nvmrc.
+type Source interface {
+	Name() string
+
+	// Priority decides the order sources are consulted in, lower comes first.
+	Priority() int
+
+	Detect(dir string) (version string, path string, err error)
+}
+
+type source struct {
+	name     string
+	priority int
+	detect   DetectFunc
+}
+
+func (s *source) Name() string { return s.name }
+
+func (s *source) Priority() int { return s.priority }
+
+func (s *source) Detect(dir string) (string, string, error) { return s.detect(dir) }
+
+// NewSource wraps fn into a Source
+func NewSource(name string, priority int, fn DetectFunc) Source {
+	return &source{name: name, priority: priority, detect: fn}
+}
+
+// FallbackFunc is like DetectFunc for files that can list more than one
+// version, in order of preference, e.g. asdf's "nodejs 20.11.0 18.20.4".
+type FallbackFunc func(dir string) (versions []string, path string, err error)
+
+// FallbackSource is implemented by sources that can list fallbacks, the
+// versions after the first are tried in order when the first can't be
+// provided
+type FallbackSource interface {
+	Source
+
+	DetectAll(dir string) (versions []string, path string, err error)
+}
+
+type fallbackSource struct {
+	name     string
+	priority int
+	detect   FallbackFunc
+}
+
+func (s *fallbackSource) Name() string { return s.name }
+
+func (s *fallbackSource) Priority() int { return s.priority }
+
+func (s *fallbackSource) DetectAll(dir string) ([]string, string, error) { return s.detect(dir) }
+
+func (s *fallbackSource) Detect(dir string) (string, string, error) {
+	versions, path, err := s.detect(dir)
+	if len(versions) == 0 {
+		return "", path, err
+	}
+
+	return versions[0], path, err
+}
+
+// NewFallbackSource wraps fn into a FallbackSource
+func NewFallbackSource(name string, priority int, fn FallbackFunc) Source {
+	return &fallbackSource{name: name, priority: priority, detect: fn}
+}
+
+// OnFail says what should happen when a detected version can't be provided,
+// named after the onFail field of package.json's devEngines
+type OnFail = string
+
+const (
+	OnFailError OnFail = "error"
+	OnFailWarn  OnFail = "warn"
+)
+
+// FailurePolicy can be implemented by a Source whose files carry their own
+// instruction for when the version can't be provided
+type FailurePolicy interface {
+	OnFail(path string) OnFail
+}
+
+// Match is a version found by a source
+type Match struct {
+	Source  Source
+	Version string
+	Path    string
+
+	// Fallbacks are tried in order when Version can't be provided
+	Fallbacks []string
+
+	// OnFail defaults to OnFailError
+	OnFail OnFail
+}
+
+// Registry is an ordered set of sources, keyed by name.
+type Registry struct {
+	sources []Source
+
+	// order, set by Ordered, puts these sources first whatever their priority
+	order []string
+}
+
+func NewRegistry(sources ...Source) *Registry {
+	r := &Registry{}
+	for _, s := range sources {
+		r.Register(s)
+	}
+	return r
+}
+
+// Register adds s to the registry, replacing any source with the same name.
+func (r *Registry) Register(s Source) {
+	for i, existing := range r.sources {
+		if existing.Name() == s.Name() {
+			r.sources[i] = s
+			return
+		}
+	}
+
+	r.sources = append(r.sources, s)
+}
+
+// Lookup returns the source registered as name
+func (r *Registry) Lookup(name string) (Source, bool) {
+	for _, s := range r.sources {
+		if s.Name() == name {
+			return s, true
+		}
+	}
+
+	return nil, false
+}
+
+// Sources returns the registered sources in the order they are consulted.
+// Sources with the same priority keep their registration order.
+func (r *Registry) Sources() []Source {
+	sources := make([]Source, len(r.sources))
+	copy(sources, r.sources)
+
+	rank := func(s Source) int {
+		for i, name := range r.order {
+			if s.Name() == name {
+				return i
+			}
+		}
+
+		return len(r.order)
+	}
+
+	sort.SliceStable(sources, func(i, j int) bool {
+		if ri, rj := rank(sources[i]), rank(sources[j]); ri != rj {
+			return ri < rj
+		}
+
+		return sources[i].Priority() < sources[j].Priority()
+	})
+
+	return sources
+}
+
+// Ordered returns a copy of r that consults the named sources first, in the
+// order given, and the rest after them by priority
+func (r *Registry) Ordered(names ...string) *Registry {
+	return &Registry{sources: append([]Source(nil), r.sources...), order: names}
+}
+
+// Without returns a copy of r without the named sources
+func (r *Registry) Without(names ...string) *Registry {
+	c := &Registry{order: r.order}
+
+	for _, s := range r.sources {
+		disabled := false
+		for _, name := range names {
+			disabled = disabled || s.Name() == name
+		}
+
+		if !disabled {
+			c.sources = append(c.sources, s)
+		}
+	}
+
+	return c
+}
+
+// Attempt is the outcome of consulting a single source in a single directory
+type Attempt struct {
+	Dir       string
+	Source    Source
+	Path      string
+	Version   string
+	Fallbacks []string
+	Err       error
+}
+
+// walk consults every source in every directory Dirs returns, until fn
+// returns false.
+func (r *Registry) walk(dir string, depth int, fn func(a Attempt) bool) {
+	sources := r.Sources()
+
+	for _, dir := range Dirs(dir, depth) {
+		for _, source := range sources {
+			if !fn(detect(source, dir)) {
+				return
+			}
+		}
+	}
+}
+
+func detect(source Source, dir string) Attempt {
+	a := Attempt{Dir: dir, Source: source}
+
+	s, ok := source.(FallbackSource)
+	if !ok {
+		a.Version, a.Path, a.Err = source.Detect(dir)
+		return a
+	}
+
+	var versions []string
+	versions, a.Path, a.Err = s.DetectAll(dir)
+
+	if len(versions) > 0 {
+		a.Version, a.Fallbacks = versions[0], versions[1:]
+	}
+
+	return a
+}
+
+// Detect walks up from dir to the project root (see Dirs), trying every
+// source in order at each level. A depth that isn't negative caps how far up
+// it goes. The first version found wins.
+func (r *Registry) Detect(dir string, depth int) (match Match, found bool) {
+	r.walk(dir, depth, func(a Attempt) bool {
+		if a.Version != "" {
+			match, found = Match{Source: a.Source, Version: a.Version, Path: a.Path, Fallbacks: a.Fallbacks, OnFail: OnFailError}, true
+
+			if p, ok := a.Source.(FailurePolicy); ok {
+				match.OnFail = p.OnFail(a.Path)
+			}
+
+			return false
+		}
+
+		if a.Err != nil {
+			log.Println("unable to parse source:", a.Source.Name(), "error:", a.Err)
+		}
+
+		return true
+	})
+
+	return
+}
+
+// Trace is like Detect but doesn't stop at the first match, it returns
+// every attempt made along the way. The first attempt with a version is the
+// one Detect would have picked.
+func (r *Registry) Trace(dir string, depth int) []Attempt {
+	var attempts []Attempt
+
+	r.walk(dir, depth, func(a Attempt) bool {
+		attempts = append(attempts, a)
+		return true
+	})
+
+	return attempts
+}
+
+// Default is the registry the novm binary detects versions with
+var Default = NewRegistry()
+
+// Register adds s to the Default registry
+func Register(s Source) {
+	Default.Register(s)
+}
+
+// Detect runs Default.Detect
+func Detect(dir string, depth int) (Match, bool) {
+	return Default.Detect(dir, depth)
+}
+
+// Trace runs Default.Trace
+func Trace(dir string, depth int) []Attempt {
+	return Default.Trace(dir, depth)
+}
@@ -0,0 +1,219 @@
+package sources
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type sourceType = string
+
+const (
+	sourceNvmFile             sourceType = ".nvmrc"
+	sourcePackageJsonFile     sourceType = "package.json"
+	sourceEnvironmentVariable sourceType = "environment"
+	sourceNodeVersionFile     sourceType = ".node-version"
+	sourceMiseFile            sourceType = "mise.toml"
+	sourceToolVersionsFile    sourceType = ".tool-versions"
+	sourceDockerfileFile      sourceType = "Dockerfile"
+	sourceGithubWorkflowsDir  sourceType = ".github/workflows"
+)
+
+// Priorities of the built-in sources, lower wins. They are spaced out so
+// custom sources can be slotted in between, e.g. PriorityNvmrc - 1 to be
+// consulted right before .nvmrc.
+const (
+	PriorityEnvironment     = 100
+	PriorityPackageJson     = 200
+	PriorityNvmrc           = 300
+	PriorityNodeVersion     = 400
+	PriorityMise            = 450
+	PriorityToolVersions    = 500
+	PriorityDockerfile      = 600
+	PriorityGithubWorkflows = 700
+)
+
+func init() {
+	// NODE_VERSION
+	Register(NewSource(sourceEnvironmentVariable, PriorityEnvironment, sourceEnvironment))
+	// devEngines, engines, volta
+	Register(packageJsonSource{NewSource(sourcePackageJsonFile, PriorityPackageJson, sourcePackageJson)})
+	Register(NewSource(sourceNvmFile, PriorityNvmrc, sourceNvmrc))
+	Register(NewSource(sourceNodeVersionFile, PriorityNodeVersion, sourceNodeVersion))
+	// also .mise.toml, .rtx.toml
+	Register(NewFallbackSource(sourceMiseFile, PriorityMise, sourceMise))
+	// asdf, mise
+	Register(NewFallbackSource(sourceToolVersionsFile, PriorityToolVersions, wrapFallbackInExperimental(sourceToolVersionsFile, sourceToolVersions)))
+	// also Containerfile, *.Dockerfile
+	Register(NewSource(sourceDockerfileFile, PriorityDockerfile, sourceDockerfile))
+	// actions/setup-node
+	Register(NewSource(sourceGithubWorkflowsDir, PriorityGithubWorkflows, wrapInExperimental(sourceGithubWorkflowsDir, sourceGithubWorkflows)))
+}
+
+// experimental are the sources wrapped by wrapInExperimental or
+// wrapFallbackInExperimental
+var experimental = map[sourceType]bool{}
+
+// IsExperimental reports whether the built-in source name is experimental,
+// NOVM_NO_EXPERIMENTAL disables those
+func IsExperimental(name string) bool {
+	return experimental[name]
+}
+
+func wrapInExperimental(source sourceType, fn DetectFunc) DetectFunc {
+	experimental[source] = true
+
+	return func(dir string) (string, string, error) {
+		v, path, err := fn(dir)
+		if err != nil || v == "" {
+			return "", path, err
+		}
+
+		warnExperimental(source)
+		return v, path, nil
+	}
+}
+
+// wrapFallbackInExperimental is wrapInExperimental for sources listing
+// fallbacks
+func wrapFallbackInExperimental(source sourceType, fn FallbackFunc) FallbackFunc {
+	experimental[source] = true
+
+	return func(dir string) ([]string, string, error) {
+		versions, path, err := fn(dir)
+		if err != nil || len(versions) == 0 {
+			return nil, path, err
+		}
+
+		warnExperimental(source)
+		return versions, path, nil
+	}
+}
+
+func warnExperimental(source sourceType) {
+	log.Printf("%s is an experimental source, and may not be behaving as expected, disable with NOVM_NO_EXPERIMENTAL=1", source)
+}
+
+func openpath(dir, name string) (path string, file io.ReadCloser, err error) {
+	path = filepath.Join(dir, name)
+	file, err = os.Open(path)
+	return
+}
+
+func sourceEnvironment(_dir string) (string, string, error) {
+	if version := os.Getenv("NODE_VERSION"); version != "" {
+		return version, "NODE_VERSION", nil
+	}
+
+	version := os.Getenv("NP_NODE_VERSION")
+	if version != "" {
+		log.Println("NP_NODE_VERSION is deprecated, use NODE_VERSION instead")
+		return version, "NP_NODE_VERSION", nil
+	}
+
+	return "", "NODE_VERSION", nil
+}
+
+// parseNvmrc reads a version the way nvm does. The first line that isn't
+// empty or a comment is used, the aliases node, stable and lts/... are passed
+// on as is, a bare major or major.minor means the newest release of it.
+func parseNvmrc(content string) (string, error) {
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.Index(line, "#"); i != -1 {
+			line = line[:i]
+		}
+
+		version := strings.ToLower(strings.TrimSpace(line))
+		if version == "" {
+			continue
+		}
+
+		switch {
+		case version == "node" || version == "stable" || strings.HasPrefix(version, "lts/"):
+			return version, nil
+		case version == "system" || strings.HasPrefix(version, "iojs"):
+			return "", fmt.Errorf("%s is not supported", version)
+		}
+
+		version = strings.TrimPrefix(version, "v")
+
+		if isPartialVersion(version) {
+			return version + ".x", nil
+		}
+
+		return version, nil
+	}
+
+	return "", nil
+}
+
+// isPartialVersion reports whether v is a major or a major.minor, nothing else
+func isPartialVersion(v string) bool {
+	parts := strings.Split(v, ".")
+	if len(parts) > 2 {
+		return false
+	}
+
+	for _, part := range parts {
+		if part == "" || strings.Trim(part, "0123456789") != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+func sourceNvmrc(dir string) (string, string, error) {
+	path, f, err := openpath(dir, ".nvmrc")
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", path, nil
+		}
+
+		return "", path, fmt.Errorf("failed to read .nvmrc: %w", err)
+	}
+
+	defer f.Close()
+
+	b, err := io.ReadAll(f)
+	if err != nil {
+		return "", path, fmt.Errorf("failed to read .nvmrc: %w", err)
+	}
+
+	version, err := parseNvmrc(string(b))
+	if err != nil {
+		return "", path, fmt.Errorf("failed to parse .nvmrc: %w", err)
+	}
+
+	return version, path, nil
+}
+
+func sourceNodeVersion(dir string) (string, string, error) {
+	// same format as .nvmrc, supported by nodenv, n, fnm, Volta, asdf
+	path := filepath.Join(dir, ".node-version")
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", path, nil
+		}
+
+		return "", path, fmt.Errorf("failed to read .node-version: %w", err)
+	}
+
+	defer f.Close()
+
+	b, err := io.ReadAll(f)
+	if err != nil {
+		return "", path, fmt.Errorf("failed to read .node-version: %w", err)
+	}
+
+	version, err := parseNvmrc(string(b))
+	if err != nil {
+		return "", path, fmt.Errorf("failed to parse .node-version: %w", err)
+	}
+
+	return version, path, nil
+}
@@ -0,0 +1,503 @@
+package sources
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+}
+
+func TestDefaultOrder(t *testing.T) {
+	// must match the priority table in docs/usage.md
+	expected := []string{"environment", "package.json", ".nvmrc", ".node-version", "mise.toml", ".tool-versions", "Dockerfile", ".github/workflows"}
+
+	got := Default.Sources()
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d sources, got %d", len(expected), len(got))
+	}
+
+	for i, source := range got {
+		if source.Name() != expected[i] {
+			t.Fatalf("expected source %d to be %s, got %s", i, expected[i], source.Name())
+		}
+	}
+}
+
+func TestDetectPrecedence(t *testing.T) {
+	t.Setenv("NODE_VERSION", "")
+	t.Setenv("NP_NODE_VERSION", "")
+
+	dir := t.TempDir()
+	writeFile(t, dir, ".nvmrc", "18.20.0\n")
+	writeFile(t, dir, ".node-version", "16.20.2\n")
+	writeFile(t, dir, "package.json", `{"engines": {"node": "^20"}}`)
+
+	// map iteration used to make this flaky, run it enough times to notice
+	for i := 0; i < 50; i++ {
+		m, ok := Detect(dir, 0)
+		if !ok {
+			t.Fatal("expected a version to be detected")
+		}
+
+		if m.Source.Name() != sourcePackageJsonFile || m.Version != "^20" {
+			t.Fatalf("expected ^20 from package.json, got %s from %s", m.Version, m.Source.Name())
+		}
+	}
+
+	t.Setenv("NODE_VERSION", "22.1.0")
+
+	m, _ := Detect(dir, 0)
+	if m.Version != "22.1.0" {
+		t.Fatalf("expected NODE_VERSION to win, got %s from %s", m.Version, m.Source.Name())
+	}
+}
+
+func TestDetectNearestDirectoryWins(t *testing.T) {
+	t.Setenv("NODE_VERSION", "")
+	t.Setenv("NP_NODE_VERSION", "")
+
+	parent := t.TempDir()
+	child := filepath.Join(parent, "child")
+	if err := os.Mkdir(child, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, parent, "package.json", `{"engines": {"node": "^20"}}`)
+	writeFile(t, child, ".node-version", "16.20.2")
+
+	m, ok := Detect(child, 1)
+	if !ok || m.Version != "16.20.2" {
+		t.Fatalf("expected the closer .node-version to win, got %q", m.Version)
+	}
+}
+
+func TestRegistryCustomSource(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, ".nvmrc", "18.20.0")
+
+	r := NewRegistry(Default.Sources()...)
+	r.Register(NewSource("custom", PriorityNvmrc-1, func(dir string) (string, string, error) {
+		return "20.0.0", "custom", nil
+	}))
+
+	t.Setenv("NODE_VERSION", "")
+	t.Setenv("NP_NODE_VERSION", "")
+
+	m, _ := r.Detect(dir, 0)
+	if m.Source.Name() != "custom" {
+		t.Fatalf("expected custom source to win over .nvmrc, got %s", m.Source.Name())
+	}
+
+	// registering under an existing name replaces it
+	r.Register(NewSource("custom", PriorityDockerfile+1, func(dir string) (string, string, error) {
+		return "20.0.0", "custom", nil
+	}))
+
+	m, _ = r.Detect(dir, 0)
+	if m.Source.Name() != sourceNvmFile {
+		t.Fatalf("expected .nvmrc to win over the re-registered custom source, got %s", m.Source.Name())
+	}
+}
+
+func TestParseNvmrc(t *testing.T) {
+	cases := map[string]string{
+		"20.11.0\n":                        "20.11.0",
+		"v20.11.0":                         "20.11.0",
+		"  v18  \n":                        "18.x",
+		"20.11":                            "20.11.x",
+		"node":                             "node",
+		"stable":                           "stable",
+		"lts/*":                            "lts/*",
+		"lts/Iron":                         "lts/iron",
+		"lts/-1":                           "lts/-1",
+		"# pinned for the api\n\n20\n":     "20.x",
+		"20.11.0 # matches the dockerfile": "20.11.0",
+		"^20 # constraints still work":     "^20",
+		">=18 <21":                         ">=18 <21",
+		"":                                 "",
+		"# nothing here":                   "",
+	}
+
+	for content, expected := range cases {
+		got, err := parseNvmrc(content)
+		if err != nil {
+			t.Fatalf("%q: unexpected error %v", content, err)
+		}
+
+		if got != expected {
+			t.Fatalf("%q: expected %q, got %q", content, expected, got)
+		}
+	}
+
+	for _, content := range []string{"system", "iojs", "iojs-v3"} {
+		if _, err := parseNvmrc(content); err == nil {
+			t.Fatalf("expected %q to be rejected", content)
+		}
+	}
+}
+
+func TestPackageJsonDevEngines(t *testing.T) {
+	cases := map[string]struct {
+		content string
+		version string
+		onFail  OnFail
+	}{
+		"object": {
+			`{"devEngines": {"runtime": {"name": "node", "version": "^22"}}, "engines": {"node": ">=18"}}`,
+			"^22", OnFailError,
+		},
+		"array": {
+			`{"devEngines": {"runtime": [{"name": "bun", "version": "1"}, {"name": "node", "version": "20.x", "onFail": "warn"}]}}`,
+			"20.x", OnFailWarn,
+		},
+		"ignored": {
+			`{"devEngines": {"runtime": {"name": "node", "version": "^22", "onFail": "ignore"}}, "engines": {"node": ">=18"}}`,
+			">=18", OnFailError,
+		},
+		"other runtime": {
+			`{"devEngines": {"runtime": {"name": "deno", "version": "2"}}, "volta": {"node": "20.11.0"}}`,
+			"20.11.0", OnFailError,
+		},
+	}
+
+	t.Setenv("NODE_VERSION", "")
+	t.Setenv("NP_NODE_VERSION", "")
+
+	for name, c := range cases {
+		dir := t.TempDir()
+		writeFile(t, dir, "package.json", c.content)
+
+		m, ok := Detect(dir, 0)
+		if !ok {
+			t.Fatalf("%s: expected a version", name)
+		}
+
+		if m.Version != c.version || m.OnFail != c.onFail {
+			t.Fatalf("%s: expected %s (onFail %s), got %s (onFail %s)", name, c.version, c.onFail, m.Version, m.OnFail)
+		}
+	}
+}
+
+func TestDetectPackageManager(t *testing.T) {
+	parent := t.TempDir()
+	child := filepath.Join(parent, "packages", "app")
+	if err := os.MkdirAll(child, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, parent, "package.json", `{"packageManager": "pnpm@9.1.0+sha512.abcdef"}`)
+	writeFile(t, child, "package.json", `{"name": "app"}`)
+
+	name, version, ok := DetectPackageManager(child, 2)
+	if !ok || name != "pnpm" || version != "9.1.0" {
+		t.Fatalf("expected pnpm 9.1.0, got %q %q", name, version)
+	}
+
+	writeFile(t, child, "package.json", `{"devEngines": {"packageManager": {"name": "yarn", "version": "4.x"}}}`)
+
+	name, version, ok = DetectPackageManager(child, 2)
+	if !ok || name != "yarn" || version != "4.x" {
+		t.Fatalf("expected yarn 4.x, got %q %q", name, version)
+	}
+}
+
+func TestDirsStopAtBoundary(t *testing.T) {
+	root := t.TempDir()
+	deep := filepath.Join(root, "repo", "packages", "foo", "src", "lib")
+	if err := os.MkdirAll(deep, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := filepath.Join(root, "repo")
+
+	if err := os.Mkdir(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// unrelated file above the repository, must never be picked up
+	writeFile(t, root, ".nvmrc", "16.20.2")
+	writeFile(t, repo, ".nvmrc", "20.11.0")
+
+	dirs := Dirs(deep, -1)
+	if len(dirs) != 5 || dirs[len(dirs)-1] != repo {
+		t.Fatalf("expected to walk up to %s in 5 steps, got %v", repo, dirs)
+	}
+
+	t.Setenv("NODE_VERSION", "")
+	t.Setenv("NP_NODE_VERSION", "")
+
+	m, ok := Detect(deep, -1)
+	if !ok || m.Version != "20.11.0" {
+		t.Fatalf("expected the repository's .nvmrc, got %q", m.Version)
+	}
+
+	// depth still caps the walk
+	if _, ok := Detect(deep, 2); ok {
+		t.Fatal("expected nothing within 2 levels")
+	}
+
+	// a workspace root is a boundary too, even inside a repository
+	workspace := filepath.Join(repo, "packages")
+	writeFile(t, workspace, "package.json", `{"workspaces": ["foo"]}`)
+
+	if dirs := Dirs(deep, -1); dirs[len(dirs)-1] != workspace {
+		t.Fatalf("expected to stop at the workspace root %s, got %v", workspace, dirs)
+	}
+
+	writeFile(t, workspace, "package.json", `{"name": "not-a-workspace"}`)
+	writeFile(t, workspace, "pnpm-workspace.yaml", "packages:\n  - foo\n")
+
+	if dirs := Dirs(deep, -1); dirs[len(dirs)-1] != workspace {
+		t.Fatalf("expected to stop at the pnpm workspace root %s, got %v", workspace, dirs)
+	}
+}
+
+func TestParseDockerfile(t *testing.T) {
+	cases := map[string]string{
+		"FROM node:20.11.0\n":                          "20.11.0",
+		"FROM\tnode:18-alpine":                         "18.x",
+		"from node:20.11-bookworm-slim":                "20.11.x",
+		"FROM --platform=linux/amd64 node:20 AS build": "20.x",
+		"FROM node":                                      "latest",
+		"FROM node:alpine":                               "latest",
+		"FROM node:current-alpine3.19":                   "latest",
+		"FROM node:lts-alpine":                           "lts",
+		"FROM node:iron-slim":                            "iron",
+		"FROM docker.io/library/node:22@sha256:0123abcd": "22.x",
+		"FROM\n":                       "",
+		"FROM golang:1.22":             "",
+		"# FROM node:16\nFROM node:18": "18.x",
+		"ARG NODE_VERSION=20.11.0\nFROM node:${NODE_VERSION}-alpine":                                                     "20.11.0",
+		"ARG NODE_VERSION\nFROM node:${NODE_VERSION:-18}":                                                                "18.x",
+		"ARG NODE_VERSION\nFROM node:${NODE_VERSION}-alpine":                                                             "",
+		"ARG NODE_VERSION\nFROM node:$NODE_VERSION":                                                                      "",
+		"ARG NODE_VERSION\nFROM node:18 AS deps\nFROM node:${NODE_VERSION}-slim":                                         "",
+		"ARG V=\"22\"\nFROM node:$V":                                                                                     "22.x",
+		"FROM node:20 AS base\nFROM base AS deps\nRUN npm ci\nFROM deps":                                                 "20.x",
+		"FROM node:20 AS build\nRUN npm run build\nFROM nginx:alpine\nCOPY --from=build /app/dist /usr/share/nginx/html": "20.x",
+		"FROM node:18 AS old\nFROM node:22 AS new\nFROM old":                                                             "18.x",
+		"FROM \\\n  --platform=$BUILDPLATFORM \\\n  node:20.11.1":                                                        "20.11.1",
+	}
+
+	for content, expected := range cases {
+		got, err := parseDockerfile(strings.NewReader(content))
+		if err != nil {
+			t.Fatalf("%q: %v", content, err)
+		}
+
+		if got != expected {
+			t.Fatalf("%q: expected %q, got %q", content, expected, got)
+		}
+	}
+}
+
+func TestDockerfileNames(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "api.Dockerfile", "FROM node:18")
+
+	v, path, err := sourceDockerfile(dir)
+	if err != nil || v != "18.x" || filepath.Base(path) != "api.Dockerfile" {
+		t.Fatalf("expected 18.x from api.Dockerfile, got %q from %s (%v)", v, path, err)
+	}
+
+	writeFile(t, dir, "Containerfile", "FROM node:20")
+
+	if v, _, _ := sourceDockerfile(dir); v != "20.x" {
+		t.Fatalf("expected Containerfile to win over api.Dockerfile, got %q", v)
+	}
+
+	writeFile(t, dir, "Dockerfile", "FROM node:22")
+
+	if v, _, _ := sourceDockerfile(dir); v != "22.x" {
+		t.Fatalf("expected Dockerfile to win, got %q", v)
+	}
+}
+
+func TestGithubWorkflows(t *testing.T) {
+	cases := []struct {
+		name, content, expected string
+	}{
+		{"plain", `
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - uses: actions/setup-node@v4
+        with:
+          node-version: 20.10
+`, "20.10.x"},
+		{"matrix, highest", `
+jobs:
+  test:
+    strategy:
+      matrix:
+        node: [18.x, 22.x, 20.x]
+    steps:
+      - uses: actions/setup-node@v4
+        with:
+          node-version: ${{ matrix.node }}
+`, "22.x"},
+		{"matrix, primary", `
+jobs:
+  test:
+    strategy:
+      matrix:
+        node-version: [18, 20, 22]
+        include:
+          - node-version: 20
+            primary: true
+    steps:
+      - uses: actions/setup-node@v3
+        with:
+          node-version: ${{ matrix.node-version }}
+`, "20.x"},
+		{"env", `
+env:
+  NODE: lts/iron
+jobs:
+  build:
+    steps:
+      - uses: actions/setup-node@v4
+        with:
+          node-version: ${{ env.NODE }}
+`, "lts/iron"},
+		{"jobs in order", `
+jobs:
+  lint:
+    steps:
+      - uses: actions/setup-node@v4
+        with:
+          node-version: 18.20.4
+  test:
+    steps:
+      - uses: actions/setup-node@v4
+        with:
+          node-version: 22
+`, "18.20.4"},
+		{"unknown expression", `
+jobs:
+  test:
+    strategy:
+      matrix: ${{ fromJSON(needs.setup.outputs.matrix) }}
+    steps:
+      - uses: actions/setup-node@v4
+        with:
+          node-version: ${{ inputs.node }}
+`, ""},
+	}
+
+	for _, c := range cases {
+		dir := t.TempDir()
+		if err := os.MkdirAll(filepath.Join(dir, ".github", "workflows"), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		writeFile(t, filepath.Join(dir, ".github", "workflows"), "ci.yml", c.content)
+
+		v, _, err := sourceGithubWorkflows(dir)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+
+		if v != c.expected {
+			t.Fatalf("%s: expected %q, got %q", c.name, c.expected, v)
+		}
+	}
+}
+
+func TestGithubWorkflowsVersionFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, ".github", "workflows"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, filepath.Join(dir, ".github", "workflows"), "release.yaml", `
+jobs:
+  release:
+    steps:
+      - uses: actions/setup-node@v4
+        with:
+          node-version-file: .nvmrc
+`)
+
+	writeFile(t, dir, ".nvmrc", "lts/*\n")
+
+	v, path, err := sourceGithubWorkflows(dir)
+	if err != nil || v != "lts/*" || filepath.Base(path) != "release.yaml" {
+		t.Fatalf("expected lts/* from release.yaml, got %q from %s (%v)", v, path, err)
+	}
+}
+
+func TestToolVersions(t *testing.T) {
+	cases := map[string][]string{
+		"nodejs 20.11.0\n":                       {"20.11.0"},
+		"python 3.12.1\nnode 20 # comment\n":     {"20.x"},
+		"nodejs 20.11.0 system 18.20.4":          {"20.11.0", "18.20.4"},
+		"nodejs lts-hydrogen latest:20 ref:main": {"lts/hydrogen", "20.x"},
+		"ruby 3.3.0\n":                           nil,
+	}
+
+	for content, expected := range cases {
+		got, err := parseToolVersions(strings.NewReader(content))
+		if err != nil {
+			t.Fatalf("%q: %v", content, err)
+		}
+
+		if strings.Join(got, " ") != strings.Join(expected, " ") {
+			t.Fatalf("%q: expected %v, got %v", content, expected, got)
+		}
+	}
+
+	if _, err := parseToolVersions(strings.NewReader("nodejs system")); err == nil {
+		t.Fatal("expected an error when no listed version is supported")
+	}
+
+	if !IsExperimental(sourceToolVersionsFile) {
+		t.Fatal("expected .tool-versions to be experimental")
+	}
+}
+
+func TestMise(t *testing.T) {
+	cases := map[string][]string{
+		"[tools]\nnode = \"20\"\n":                                  {"20.x"},
+		"[tools]\nnodejs = [\"22.11.0\", \"prefix:20\"]\n":          {"22.11.0", "20.x"},
+		"[tools]\nnode = { version = \"lts\", postinstall = \"\" }": {"lts"},
+		"[tools]\npython = \"3.12\"\n":                              nil,
+		"[env]\nNODE_ENV = \"production\"\n":                        nil,
+	}
+
+	for content, expected := range cases {
+		got, err := parseMise([]byte(content))
+		if err != nil {
+			t.Fatalf("%q: %v", content, err)
+		}
+
+		if strings.Join(got, " ") != strings.Join(expected, " ") {
+			t.Fatalf("%q: expected %v, got %v", content, expected, got)
+		}
+	}
+
+	t.Setenv("NODE_VERSION", "")
+	t.Setenv("NP_NODE_VERSION", "")
+
+	dir := t.TempDir()
+	writeFile(t, dir, ".tool-versions", "nodejs 18.20.4\n")
+	writeFile(t, dir, ".rtx.toml", "[tools]\nnode = [\"21.7.3\", \"20.12.2\"]\n")
+
+	m, ok := Detect(dir, 0)
+	if !ok || m.Source.Name() != sourceMiseFile || m.Path != filepath.Join(dir, ".rtx.toml") {
+		t.Fatalf("expected .rtx.toml to win over .tool-versions, got %+v", m)
+	}
+
+	if m.Version != "21.7.3" || len(m.Fallbacks) != 1 || m.Fallbacks[0] != "20.12.2" {
+		t.Fatalf("expected 21.7.3 falling back to 20.12.2, got %s %v", m.Version, m.Fallbacks)
+	}
+}
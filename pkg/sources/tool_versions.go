@@ -0,0 +1,188 @@
+package sources
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// toolNames are the names asdf and mise know the nodejs plugin by
+var toolNames = []string{"nodejs", "node", "core:node"}
+
+func isNodeTool(name string) bool {
+	for _, n := range toolNames {
+		if name == n {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sourceToolVersions(dir string) ([]string, string, error) {
+	// asdf/mise format: lines of "<plugin> <version> [<version>...]"
+	path, f, err := openpath(dir, ".tool-versions")
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, path, nil
+		}
+
+		return nil, path, fmt.Errorf("failed to read .tool-versions: %w", err)
+	}
+
+	defer f.Close()
+
+	versions, err := parseToolVersions(f)
+	if err != nil {
+		return nil, path, fmt.Errorf("failed to read .tool-versions: %w", err)
+	}
+
+	return versions, path, nil
+}
+
+// parseToolVersions returns the versions listed for nodejs, in the order
+// asdf tries them
+func parseToolVersions(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i != -1 {
+			line = line[:i]
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && isNodeTool(fields[0]) {
+			return toolVersions(fields[1:])
+		}
+	}
+
+	return nil, scanner.Err()
+}
+
+// miseFiles are read in this order, the first one that sets nodejs wins
+var miseFiles = []string{"mise.toml", ".mise.toml", ".rtx.toml"}
+
+type miseConfig struct {
+	Tools map[string]interface{} `toml:"tools"`
+}
+
+func sourceMise(dir string) ([]string, string, error) {
+	for _, name := range miseFiles {
+		path := filepath.Join(dir, name)
+
+		b, err := os.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+
+			return nil, path, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+
+		versions, err := parseMise(b)
+		if err != nil {
+			return nil, path, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+
+		if len(versions) > 0 {
+			return versions, path, nil
+		}
+	}
+
+	return nil, filepath.Join(dir, miseFiles[0]), nil
+}
+
+// parseMise returns the versions [tools] sets nodejs to. mise takes a
+// version, a list of them, or a table with a version key.
+func parseMise(b []byte) ([]string, error) {
+	var config miseConfig
+	if err := toml.Unmarshal(b, &config); err != nil {
+		return nil, err
+	}
+
+	for _, name := range toolNames {
+		value, ok := config.Tools[name]
+		if !ok {
+			continue
+		}
+
+		var versions []string
+
+		items, ok := value.([]interface{})
+		if !ok {
+			items = []interface{}{value}
+		}
+
+		for _, item := range items {
+			if table, ok := item.(map[string]interface{}); ok {
+				item = table["version"]
+			}
+
+			version, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("unexpected value for tools.%s: %v", name, item)
+			}
+
+			versions = append(versions, version)
+		}
+
+		return toolVersions(versions)
+	}
+
+	return nil, nil
+}
+
+// toolVersions maps asdf/mise versions onto what novm understands, those it
+// can't provide (system, path:..., ref:...) are left out
+func toolVersions(versions []string) ([]string, error) {
+	var specs []string
+
+	for _, v := range versions {
+		if spec, ok := toolVersion(v); ok {
+			specs = append(specs, spec)
+		}
+	}
+
+	if len(specs) == 0 && len(versions) > 0 {
+		return nil, fmt.Errorf("none of %s is supported", strings.Join(versions, ", "))
+	}
+
+	return specs, nil
+}
+
+func toolVersion(v string) (string, bool) {
+	v = strings.ToLower(strings.TrimSpace(v))
+
+	switch {
+	case v == "latest" || v == "lts":
+		return v, true
+	case strings.HasPrefix(v, "latest:"):
+		v = strings.TrimPrefix(v, "latest:")
+	case strings.HasPrefix(v, "prefix:"):
+		v = strings.TrimPrefix(v, "prefix:")
+	case strings.HasPrefix(v, "lts-"):
+		// lts-hydrogen, lts-1 is left for novm's own lts offsets
+		if line := strings.TrimPrefix(v, "lts-"); strings.Trim(line, "0123456789") != "" {
+			return "lts/" + line, true
+		}
+
+		return v, true
+	}
+
+	// system, path:..., ref:..., sub-1:...
+	if v == "" || strings.Contains(v, ":") {
+		return "", false
+	}
+
+	version, err := parseNvmrc(v)
+	if err != nil || version == "" {
+		return "", false
+	}
+
+	return version, true
+}
@@ -0,0 +1,48 @@
+package sources
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// boundaryMarkers mark the root of a repository or a workspace, sources
+// aren't looked for above the first directory containing one of these
+var boundaryMarkers = []string{".git", ".hg", "pnpm-workspace.yaml", "lerna.json", "nx.json"}
+
+// IsBoundary reports whether dir is the root of a repository or of a
+// workspace, i.e. has one of boundaryMarkers or a package.json with workspaces
+func IsBoundary(dir string) bool {
+	for _, marker := range boundaryMarkers {
+		if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+			return true
+		}
+	}
+
+	p, err := readPackageJson(filepath.Join(dir, "package.json"))
+
+	return err == nil && len(p.Workspaces) > 0 && string(p.Workspaces) != "null"
+}
+
+// Dirs returns dir followed by its parents, up to and including the closest
+// boundary (see IsBoundary) or the filesystem root. A depth that isn't
+// negative caps how many parents are returned.
+func Dirs(dir string, depth int) []string {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+
+	var dirs []string
+
+	for i := 0; depth < 0 || i <= depth; i++ {
+		dirs = append(dirs, dir)
+
+		parent := filepath.Dir(dir)
+		if parent == dir || IsBoundary(dir) {
+			break
+		}
+
+		dir = parent
+	}
+
+	return dirs
+}
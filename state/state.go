@@ -23,8 +23,14 @@ type State struct {
 		Usage          map[version]lastHitState `json:"usage"`
 		LastControlled time.Time                `json:"lastControlled,omitempty"`
 	} `json:"poolControl"`
+
+	// Retention is how long after its last use a rarely used version is
+	// removed, zero keeps them forever
+	Retention time.Duration `json:"-"`
 }
 
+const defaultRetention = time.Hour * 24 * 10
+
 type updateState struct {
 	LastChecked  time.Time `json:"lastChecked"`
 	TimesChecked int       `json:"timesChecked"`
@@ -57,13 +63,16 @@ func NewState() (*State, error) {
 	f, err := os.Open(filepath.Join(root, "state.json"))
 	if err != nil {
 		if os.IsNotExist(err) {
-			return &State{}, nil
+			state := &State{Retention: defaultRetention}
+			state.PoolControl.Usage = make(map[version]lastHitState)
+
+			return state, nil
 		}
 
 		return nil, err
 	}
 
-	var state State
+	state := State{Retention: defaultRetention}
 
 	if err := json.NewDecoder(f).Decode(&state); err != nil {
 		return nil, withFileContents(f, err)
@@ -130,8 +139,8 @@ func (s *State) IncPoolHit(v version) error {
 	return s.Save()
 }
 
-func (l *lastHitState) hasItBeen10DaysSinceLastUsed() bool {
-	return time.Since(l.LastUsed) > time.Hour*24*10
+func (l *lastHitState) unusedFor(d time.Duration) bool {
+	return time.Since(l.LastUsed) > d
 }
 
 func (l *lastHitState) hasUsageAveragedOver10TimesPer3Days() bool {
@@ -141,7 +150,11 @@ func (l *lastHitState) hasUsageAveragedOver10TimesPer3Days() bool {
 
 func (s *State) ShouldClearPoolCache(v version) bool {
 	control, exists := s.PoolControl.Usage[v]
-	if exists && control.hasItBeen10DaysSinceLastUsed() && !control.hasUsageAveragedOver10TimesPer3Days() {
+	if s.Retention == 0 {
+		return false
+	}
+
+	if exists && control.unusedFor(s.Retention) && !control.hasUsageAveragedOver10TimesPer3Days() {
 		return true
 	}
 	return false
@@ -18,6 +18,7 @@ import (
 
 	gopark "github.com/debdutdeb/gopark/pkg/utils"
 	"github.com/debdutdeb/novm/v3/common"
+	"github.com/debdutdeb/novm/v3/config"
 	"github.com/debdutdeb/novm/v3/internal/log"
 	st "github.com/debdutdeb/novm/v3/state"
 	"github.com/debdutdeb/novm/v3/versions"
@@ -34,7 +35,7 @@ type asset struct {
 	Url  string `json:"browser_download_url"`
 }
 
-func wrapInUpdateCheck(action func() error) error {
+func wrapInUpdateCheck(action func() error, policy string) error {
 	var wg sync.WaitGroup
 
 	wg.Add(1)
@@ -47,7 +48,7 @@ func wrapInUpdateCheck(action func() error) error {
 		wg.Done()
 	}()
 
-	updateErr := checkUpdate(&wg)
+	updateErr := checkUpdate(&wg, policy)
 
 	wg.Wait()
 
@@ -62,7 +63,7 @@ func wrapInUpdateCheck(action func() error) error {
 	return updateErr
 }
 
-func checkUpdate(wg *sync.WaitGroup) error {
+func checkUpdate(wg *sync.WaitGroup, policy string) error {
 	var (
 		err         error
 		req         *http.Request
@@ -127,6 +128,11 @@ func checkUpdate(wg *sync.WaitGroup) error {
 		return nil
 	}
 
+	if policy == config.UpdateNotify {
+		waitAndLog("novm %s is available, you are on %s", release.Tag, versions.Version)
+		return nil
+	}
+
 	var dir string
 
 	dir, err = gopark.MkdirTemp("", common.BIN_NAME)
@@ -1,6 +1,6 @@
 //go:build darwin
 
-package utils
+package tty
 
 import (
 	"os"
@@ -8,6 +8,7 @@ import (
 	"golang.org/x/sys/unix"
 )
 
+// IsInteractive reports whether stdout is a terminal
 func IsInteractive() bool {
 	_, err := unix.IoctlGetTermios(int(os.Stdout.Fd()), unix.TIOCGETA)
 	return err == nil
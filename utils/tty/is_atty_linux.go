@@ -1,6 +1,6 @@
 //go:build linux
 
-package utils
+package tty
 
 import (
 	"os"
@@ -8,6 +8,7 @@ import (
 	"golang.org/x/sys/unix"
 )
 
+// IsInteractive reports whether stdout is a terminal
 func IsInteractive() bool {
 	_, err := unix.IoctlGetTermios(int(os.Stdout.Fd()), unix.TCGETS)
 	return err == nil